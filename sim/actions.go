@@ -0,0 +1,369 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// Action represents a player's action choice
+type Action struct {
+	Type       string `json:"type"`             // Name of its ActionKind, such as "flip" or "swap"
+	SlotIndex  int    `json:"slot"`             // For flip: the slot to turn to its next value. For swap: first slot. For lock: the slot
+	SlotIndex2 int    `json:"slot2,omitempty"`  // For swap: second slot
+	Target     int    `json:"target,omitempty"` // For peek: the opponent
+}
+
+// ActionKind is one kind of manipulation. Every kind is registered in
+// actionKinds under the name its actions carry in Action.Type; the rules
+// choose which kinds a game allows.
+type ActionKind interface {
+	// Params names each number that makes up an action of this kind:
+	// "slot" for a slot index or "player" for a player ID
+	Params() []string
+
+	// Build makes the action with the given numbers, in Params order
+	Build(args []int) Action
+
+	// Args returns the numbers Build made the action from
+	Args(a Action) []int
+
+	// Targets lists every action of this kind the player could take now
+	Targets(g *Game, player *Player) []Action
+
+	// Apply performs the action on a board. Flips modify the token in place,
+	// so callers that need to undo an action should work on a clone.
+	Apply(b *ProtocolBoard, a Action)
+
+	// Equal reports whether two actions of this kind are the same
+	// manipulation, which the next player may not copy
+	Equal(a1, a2 Action) bool
+
+	// Describe names the action the way the AI logs do, such as "flip slot 3"
+	Describe(a Action) string
+
+	// Narrate tells what the action did once applied to b, such as "flips
+	// Slot 3 to Wings=Sleek"
+	Narrate(b ProtocolBoard, a Action) string
+}
+
+// Resolver is implemented by kinds whose actions do more than change the
+// board, such as showing the player a card. RunActionPhase calls Resolve
+// once the action is applied; AI search never does.
+type Resolver interface {
+	Resolve(g *Game, player *Player, a Action)
+}
+
+// actionKinds holds every kind of manipulation by name
+var actionKinds = map[string]ActionKind{
+	"flip":   flipAction{},
+	"swap":   swapAction{},
+	"rotate": rotateAction{},
+	"lock":   lockAction{},
+	"pass":   passAction{},
+	"peek":   peekAction{},
+}
+
+// defaultActions are the manipulations of the published game
+var defaultActions = []string{"flip", "swap"}
+
+// actionKindNames lists the registered kinds for error messages
+func actionKindNames() string {
+	names := make([]string, 0, len(actionKinds))
+	for name := range actionKinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// actionUsage shows how people type the allowed actions, such as
+// "flip N" or "swap N M"
+func actionUsage(names []string) string {
+	forms := make([]string, len(names))
+	for i, name := range names {
+		form := name
+		for j, param := range actionKinds[name].Params() {
+			switch {
+			case param == "player":
+				form += " P"
+			case j == 0:
+				form += " N"
+			default:
+				form += " M"
+			}
+		}
+		forms[i] = fmt.Sprintf("%q", form)
+	}
+	if len(forms) == 1 {
+		return forms[0]
+	}
+	return strings.Join(forms[:len(forms)-1], ", ") + " or " + forms[len(forms)-1]
+}
+
+// String describes the action the way the AI logs do
+func (a Action) String() string {
+	if kind, ok := actionKinds[a.Type]; ok {
+		return kind.Describe(a)
+	}
+	return a.Type
+}
+
+// apply performs an action on the board. Flips modify the token in place, so
+// callers that need to undo an action should work on a clone.
+func (b *ProtocolBoard) apply(action Action) {
+	if kind, ok := actionKinds[action.Type]; ok {
+		kind.Apply(b, action)
+	}
+}
+
+// actionsMatch returns true if two actions are the same
+func actionsMatch(a1, a2 Action) bool {
+	if a1.Type != a2.Type {
+		return false
+	}
+	kind, ok := actionKinds[a1.Type]
+	return ok && kind.Equal(a1, a2)
+}
+
+// legalActions lists every action of the kinds the rules allow, in the
+// rules' order, skipping those the no-repeat rule forbids. Under the
+// published rule that is the one copying previousAction, which AI search
+// leaves nil to consider every action; the other rules look at the
+// manipulations made so far this round.
+func (g *Game) legalActions(player *Player, previousAction *Action) []Action {
+	rule := g.Rules.NoRepeatRule()
+	actions := []Action{}
+	for _, name := range g.Rules.ActionNames() {
+		for _, action := range actionKinds[name].Targets(g, player) {
+			if rule == NoRepeatPrevious {
+				if previousAction != nil && actionsMatch(action, *previousAction) {
+					continue
+				}
+			} else if repeatForbidden(rule, g.Manipulations, g.Board, action) {
+				continue
+			}
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
+
+// movable reports whether the slot holds a token that is not locked
+func (b ProtocolBoard) movable(slot int) bool {
+	return b.Slots[slot] != nil && !b.Locked[slot]
+}
+
+// flipAction turns one tile to its next value
+type flipAction struct{}
+
+func (flipAction) Params() []string        { return []string{"slot"} }
+func (flipAction) Build(args []int) Action { return Action{Type: "flip", SlotIndex: args[0]} }
+func (flipAction) Args(a Action) []int     { return []int{a.SlotIndex} }
+
+func (flipAction) Targets(g *Game, player *Player) []Action {
+	actions := []Action{}
+	for slot := range g.Board.Slots {
+		if g.Board.movable(slot) {
+			actions = append(actions, Action{Type: "flip", SlotIndex: slot})
+		}
+	}
+	return actions
+}
+
+func (flipAction) Apply(b *ProtocolBoard, a Action) { b.Slots[a.SlotIndex].rotate() }
+func (flipAction) Equal(a1, a2 Action) bool         { return a1.SlotIndex == a2.SlotIndex }
+func (flipAction) Describe(a Action) string         { return fmt.Sprintf("flip slot %d", a.SlotIndex+1) }
+
+func (flipAction) Narrate(b ProtocolBoard, a Action) string {
+	return fmt.Sprintf("flips Slot %d to %s", a.SlotIndex+1, b.Slots[a.SlotIndex])
+}
+
+// swapAction exchanges the tiles in two slots
+type swapAction struct{}
+
+func (swapAction) Params() []string { return []string{"slot", "slot"} }
+
+func (swapAction) Build(args []int) Action {
+	return Action{Type: "swap", SlotIndex: args[0], SlotIndex2: args[1]}
+}
+
+func (swapAction) Args(a Action) []int { return []int{a.SlotIndex, a.SlotIndex2} }
+
+func (swapAction) Targets(g *Game, player *Player) []Action {
+	actions := []Action{}
+	numSlots := len(g.Board.Slots)
+	for slot1 := 0; slot1 < numSlots; slot1++ {
+		for slot2 := slot1 + 1; slot2 < numSlots; slot2++ {
+			if g.Board.movable(slot1) && g.Board.movable(slot2) {
+				actions = append(actions, Action{Type: "swap", SlotIndex: slot1, SlotIndex2: slot2})
+			}
+		}
+	}
+	return actions
+}
+
+func (swapAction) Apply(b *ProtocolBoard, a Action) {
+	b.Slots[a.SlotIndex], b.Slots[a.SlotIndex2] = b.Slots[a.SlotIndex2], b.Slots[a.SlotIndex]
+}
+
+// Equal matches swaps of the same two slots, in either order
+func (swapAction) Equal(a1, a2 Action) bool {
+	return (a1.SlotIndex == a2.SlotIndex && a1.SlotIndex2 == a2.SlotIndex2) ||
+		(a1.SlotIndex == a2.SlotIndex2 && a1.SlotIndex2 == a2.SlotIndex)
+}
+
+func (swapAction) Describe(a Action) string {
+	return fmt.Sprintf("swap slots %d+%d", a.SlotIndex+1, a.SlotIndex2+1)
+}
+
+func (swapAction) Narrate(b ProtocolBoard, a Action) string {
+	return fmt.Sprintf("swaps Slot %d and Slot %d", a.SlotIndex+1, a.SlotIndex2+1)
+}
+
+// rotateAction turns the whole ring one step: every tile moves down a slot
+// and the last slot's tile comes round to Slot 1. Any lock holds the ring
+// still.
+type rotateAction struct{}
+
+func (rotateAction) Params() []string        { return nil }
+func (rotateAction) Build(args []int) Action { return Action{Type: "rotate"} }
+func (rotateAction) Args(a Action) []int     { return nil }
+
+func (rotateAction) Targets(g *Game, player *Player) []Action {
+	for slot := range g.Board.Slots {
+		if !g.Board.movable(slot) {
+			return nil
+		}
+	}
+	return []Action{{Type: "rotate"}}
+}
+
+func (rotateAction) Apply(b *ProtocolBoard, a Action) {
+	last := b.Slots[len(b.Slots)-1]
+	copy(b.Slots[1:], b.Slots[:len(b.Slots)-1])
+	b.Slots[0] = last
+}
+
+func (rotateAction) Equal(a1, a2 Action) bool { return true }
+func (rotateAction) Describe(a Action) string { return "rotate the ring" }
+
+func (rotateAction) Narrate(b ProtocolBoard, a Action) string {
+	return fmt.Sprintf("rotates the ring, bringing %s to Slot 1", b.Slots[0])
+}
+
+// lockAction keeps one slot's tile where it is, on its current side, for the
+// rest of the Manipulate phase
+type lockAction struct{}
+
+func (lockAction) Params() []string        { return []string{"slot"} }
+func (lockAction) Build(args []int) Action { return Action{Type: "lock", SlotIndex: args[0]} }
+func (lockAction) Args(a Action) []int     { return []int{a.SlotIndex} }
+
+func (lockAction) Targets(g *Game, player *Player) []Action {
+	actions := []Action{}
+	for slot := range g.Board.Slots {
+		if g.Board.movable(slot) {
+			actions = append(actions, Action{Type: "lock", SlotIndex: slot})
+		}
+	}
+	return actions
+}
+
+func (lockAction) Apply(b *ProtocolBoard, a Action) { b.Locked[a.SlotIndex] = true }
+func (lockAction) Equal(a1, a2 Action) bool         { return a1.SlotIndex == a2.SlotIndex }
+func (lockAction) Describe(a Action) string         { return fmt.Sprintf("lock slot %d", a.SlotIndex+1) }
+
+func (lockAction) Narrate(b ProtocolBoard, a Action) string {
+	return fmt.Sprintf("locks Slot %d for the rest of the round", a.SlotIndex+1)
+}
+
+// passAction leaves the board alone. The published no-repeat rule means two
+// players in a row cannot pass.
+type passAction struct{}
+
+func (passAction) Params() []string                         { return nil }
+func (passAction) Build(args []int) Action                  { return Action{Type: "pass"} }
+func (passAction) Args(a Action) []int                      { return nil }
+func (passAction) Targets(g *Game, player *Player) []Action { return []Action{{Type: "pass"}} }
+func (passAction) Apply(b *ProtocolBoard, a Action)         {}
+func (passAction) Equal(a1, a2 Action) bool                 { return true }
+func (passAction) Describe(a Action) string                 { return "pass" }
+func (passAction) Narrate(b ProtocolBoard, a Action) string { return "passes" }
+
+// peekAction leaves the board alone and shows the player one of an
+// opponent's bees, chosen at random. The table only learns whose hand was
+// looked at.
+type peekAction struct{}
+
+func (peekAction) Params() []string        { return []string{"player"} }
+func (peekAction) Build(args []int) Action { return Action{Type: "peek", Target: args[0]} }
+func (peekAction) Args(a Action) []int     { return []int{a.Target} }
+
+func (peekAction) Targets(g *Game, player *Player) []Action {
+	actions := []Action{}
+	for _, p := range g.Players {
+		if p.ID != player.ID && len(p.Hand) > 0 {
+			actions = append(actions, Action{Type: "peek", Target: p.ID})
+		}
+	}
+	return actions
+}
+
+func (peekAction) Apply(b *ProtocolBoard, a Action) {}
+func (peekAction) Equal(a1, a2 Action) bool         { return a1.Target == a2.Target }
+func (peekAction) Describe(a Action) string         { return fmt.Sprintf("peek at player %d", a.Target) }
+
+func (peekAction) Narrate(b ProtocolBoard, a Action) string {
+	return fmt.Sprintf("peeks at one of Player %d's bees", a.Target)
+}
+
+func (peekAction) Resolve(g *Game, player *Player, a Action) {
+	hand := g.Players[a.Target].Hand
+	card := hand[g.peekRandom().Intn(len(hand))]
+	player.Peeked = append(player.Peeked, PeekedCard{Holder: a.Target, Card: card})
+}
+
+// peekRandom returns the generator peeks choose bees with, seeding it from
+// the game's seed the first time. Drawing from the game's own generator
+// would change every later deal whenever someone peeked, as with
+// NoisyAgent.random.
+func (g *Game) peekRandom() *rand.Rand {
+	if g.peekRNG == nil {
+		g.peekRNG = rand.New(rand.NewSource(^g.seed))
+	}
+	return g.peekRNG
+}
+
+// PeekedCard is an opponent's bee a player has seen by peeking
+type PeekedCard struct {
+	Holder    int  `json:"holder"`
+	Card      Card `json:"card"`
+	presented bool // The holder has presented the bee since
+}
+
+// knownHeld lists the peeked bees that have not been presented since, so
+// their holders still have them
+func (p *Player) knownHeld() []PeekedCard {
+	held := []PeekedCard{}
+	for _, peek := range p.Peeked {
+		if !peek.presented {
+			held = append(held, peek)
+		}
+	}
+	return held
+}
+
+// notePresented marks the peeks at a bee its holder has just presented.
+// With several decks another player may present a copy of the same bee,
+// which says nothing about the holder's.
+func (g *Game) notePresented(play Play) {
+	for _, player := range g.Players {
+		for i, peek := range player.Peeked {
+			if peek.Holder == play.PlayerID && peek.Card == play.Card {
+				player.Peeked[i].presented = true
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestPeekKeepsDeals checks that peeking does not draw from the deal
+// generator, so a seeded game deals the same hands whether or not anyone
+// peeked the hand before
+func TestPeekKeepsDeals(t *testing.T) {
+	secondDeal := func(peeks int) [][]Card {
+		game := NewSeededGame(3, 7, false)
+		game.DealNewHand()
+		for range peeks {
+			peekAction{}.Resolve(game, game.Players[0], Action{Type: "peek", Target: 1})
+		}
+		if len(game.Players[0].Peeked) != peeks {
+			t.Fatalf("%d peeks showed %d bees", peeks, len(game.Players[0].Peeked))
+		}
+		game.DealNewHand()
+		hands := [][]Card{}
+		for _, player := range game.Players {
+			hands = append(hands, player.Hand)
+		}
+		return hands
+	}
+
+	want := secondDeal(0)
+	for _, peeks := range []int{1, 3} {
+		if got := secondDeal(peeks); !slices.EqualFunc(got, want, slices.Equal) {
+			t.Errorf("after %d peeks the next deal was %v, want %v", peeks, got, want)
+		}
+	}
+}
+
+// TestPeekSurvivesOtherCopies checks that with two decks, a peeked bee
+// still counts as held when an opponent presents the other copy, and not
+// once its holder presents it
+func TestPeekSurvivesOtherCopies(t *testing.T) {
+	deck := CreateDeck(printedEdition)
+	peeked := deck[5]
+	game := NewSeededGame(3, 1, false)
+	game.Rules.Decks = 2
+	game.CurrentLeader = 0
+	game.Players[0].Hand = []Card{deck[0], deck[1]}
+	game.Players[1].Hand = []Card{deck[2], peeked}
+	game.Players[2].Hand = []Card{peeked, deck[3]}
+	game.Players[0].Peeked = []PeekedCard{{Holder: 1, Card: peeked}}
+	for range game.Players {
+		game.Agents = append(game.Agents, scriptedAgent{})
+	}
+
+	game.RunPlayPhase() // Player 2 presents the other copy
+	if held := game.Players[0].knownHeld(); len(held) != 1 || held[0].Card != peeked {
+		t.Fatalf("after an opponent presented the other copy, the player knows of %v", held)
+	}
+	game.RunPlayPhase() // Player 1 presents the peeked copy
+	if held := game.Players[0].knownHeld(); len(held) != 0 {
+		t.Fatalf("after its holder presented it, the player still knows of %v", held)
+	}
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// serverAgents are the agent specs a client may ask for. Specs that read
+// files or run programs are left out, since clients are not trusted.
+var serverAgents = map[string]bool{
+	"greedy": true, "leader": true, "heuristic": true,
+	"easy": true, "medium": true, "hard": true,
+}
+
+// humanSeat marks a seat played through the API in a create request
+const humanSeat = "human"
+
+// Limits on what a client may ask of a server, so that one request cannot
+// make it deal a huge deck, play for ever or remember arbitrary names
+const (
+	maxRequestBytes = 1 << 16 // Size of a request body
+	maxClientDeck   = 1024    // Bees in a game, counting every copy
+	maxClientTarget = 100     // Score needed to win
+	maxClientName   = 32      // Length of an attribute or value name
+)
+
+// validateClientRules checks rules a client sent: within the server's
+// limits, then playable. The limits are checked first so that rules beyond
+// them never reach EditionFor.
+func validateClientRules(rules RuleConfig, numPlayers int) error {
+	if len(rules.Attributes) > MaxAttributes {
+		return fmt.Errorf("an edition has at most %d attributes", MaxAttributes)
+	}
+	size := max(rules.Decks, 1)
+	for _, attr := range rules.Attributes {
+		if len(attr.Values) > MaxValues {
+			return fmt.Errorf("attributes have at most %d values", MaxValues)
+		}
+		size *= max(len(attr.Values), 1)
+		for _, name := range append([]string{attr.Name}, attr.Values...) {
+			if len(name) > maxClientName {
+				return fmt.Errorf("names are at most %d characters long", maxClientName)
+			}
+		}
+	}
+	if rules.Attributes == nil {
+		size *= printedEdition.DeckSize()
+	}
+	if size > maxClientDeck {
+		return fmt.Errorf("the server deals at most %d bees, counting every deck", maxClientDeck)
+	}
+	if rules.TargetTricks > maxClientTarget || rules.TargetScore > maxClientTarget {
+		return fmt.Errorf("the server plays to a target of at most %d", maxClientTarget)
+	}
+	if err := rules.Validate(numPlayers); err != nil {
+		return err
+	}
+	if rules.Target(numPlayers) > maxClientTarget {
+		return fmt.Errorf("the server plays to a target of at most %d", maxClientTarget)
+	}
+	return nil
+}
+
+// apiIdleLimit is how long a game is kept after the last request for it,
+// whether or not it is over
+const apiIdleLimit = 30 * time.Minute
+
+// CreateGameRequest is the body of POST /games
+type CreateGameRequest struct {
+	Players int         `json:"players"`
+	Agents  []string    `json:"agents"` // One per seat: "human" or a server agent; default human in seat 0, greedy elsewhere
+	Seed    *int64      `json:"seed"`   // Random if omitted
+	Rules   *RuleConfig `json:"rules"`  // Standard rules for anything omitted
+}
+
+// APIServer serves games over HTTP with JSON bodies:
+//
+//	POST   /games                          create a game, returns the public view
+//	GET    /games/{id}                     public view
+//	DELETE /games/{id}                     abandon a game
+//	GET    /games/{id}/players/{seat}      the seat's view, including its hand
+//	GET    /games/{id}/players/{seat}/moves  the seat's open decision and legal moves
+//	POST   /games/{id}/players/{seat}/moves  make a move, returns the seat's view
+//
+// Server-side agents play their seats as soon as it is their turn, so every
+// response shows the game waiting on a human seat or finished. Games nobody
+// asks about for apiIdleLimit are abandoned and forgotten.
+type APIServer struct {
+	mux *http.ServeMux
+
+	idle time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]*Session
+	used     map[string]time.Time // When each game was last asked about
+	nextID   int
+}
+
+// NewAPIServer creates a server with no games
+func NewAPIServer() *APIServer {
+	s := &APIServer{
+		mux:      http.NewServeMux(),
+		idle:     apiIdleLimit,
+		sessions: make(map[string]*Session),
+		used:     make(map[string]time.Time),
+	}
+	s.mux.HandleFunc("POST /games", s.createGame)
+	s.mux.HandleFunc("GET /games/{id}", s.publicView)
+	s.mux.HandleFunc("DELETE /games/{id}", s.deleteGame)
+	s.mux.HandleFunc("GET /games/{id}/players/{seat}", s.playerView)
+	s.mux.HandleFunc("GET /games/{id}/players/{seat}/moves", s.legalMoves)
+	s.mux.HandleFunc("POST /games/{id}/players/{seat}/moves", s.submitMove)
+	return s
+}
+
+func (s *APIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *APIServer) createGame(w http.ResponseWriter, r *http.Request) {
+	req := CreateGameRequest{Players: 4}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %v", err))
+		return
+	}
+	session, err := s.newSession(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	session.Start()
+	writeJSON(w, http.StatusCreated, session.View(-1))
+}
+
+// newSession builds and registers a session from a create request
+func (s *APIServer) newSession(req CreateGameRequest) (*Session, error) {
+	if req.Players < 2 || req.Players > 5 {
+		return nil, fmt.Errorf("players must be between 2 and 5")
+	}
+	rules := DefaultRules()
+	if req.Rules != nil {
+		rules = *req.Rules
+	}
+	if err := validateClientRules(rules, req.Players); err != nil {
+		return nil, err
+	}
+	if req.Agents == nil {
+		req.Agents = []string{humanSeat}
+		for len(req.Agents) < req.Players {
+			req.Agents = append(req.Agents, "greedy")
+		}
+	}
+	if len(req.Agents) != req.Players {
+		return nil, fmt.Errorf("expected %d agents, got %d", req.Players, len(req.Agents))
+	}
+
+	agents := make([]Agent, req.Players)
+	for seat, spec := range req.Agents {
+		if spec == humanSeat {
+			continue
+		}
+		if !serverAgents[spec] {
+			return nil, fmt.Errorf("unknown agent %q", spec)
+		}
+		newAgent, err := ParseAgent(spec)
+		if err != nil {
+			return nil, err
+		}
+		agents[seat] = newAgent()
+	}
+
+	seed := rand.Int63()
+	if req.Seed != nil {
+		seed = *req.Seed
+	}
+	game := NewSeededGame(req.Players, seed, false)
+	game.Rules = rules
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	id := strconv.Itoa(s.nextID)
+	session := NewSession(id, game, agents)
+	s.sessions[id] = session
+	s.used[id] = time.Now()
+	time.AfterFunc(s.idle, func() { s.expire(session) })
+	return session, nil
+}
+
+func (s *APIServer) publicView(w http.ResponseWriter, r *http.Request) {
+	if session := s.session(w, r); session != nil {
+		writeJSON(w, http.StatusOK, session.View(-1))
+	}
+}
+
+func (s *APIServer) deleteGame(w http.ResponseWriter, r *http.Request) {
+	session := s.session(w, r)
+	if session == nil {
+		return
+	}
+	s.remove(session)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *APIServer) playerView(w http.ResponseWriter, r *http.Request) {
+	if session, seat := s.seat(w, r); session != nil {
+		writeJSON(w, http.StatusOK, session.View(seat))
+	}
+}
+
+func (s *APIServer) legalMoves(w http.ResponseWriter, r *http.Request) {
+	session, seat := s.seat(w, r)
+	if session == nil {
+		return
+	}
+	decision := session.Decision(seat)
+	if decision == nil {
+		decision = &Decision{Moves: []Move{}}
+	}
+	writeJSON(w, http.StatusOK, decision)
+}
+
+func (s *APIServer) submitMove(w http.ResponseWriter, r *http.Request) {
+	session, seat := s.seat(w, r)
+	if session == nil {
+		return
+	}
+	var move Move
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&move); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid move: %v", err))
+		return
+	}
+	if err := session.Submit(seat, move); err != nil {
+		status := http.StatusConflict
+		if errors.Is(err, errIllegalMove) {
+			status = http.StatusBadRequest
+		}
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, session.View(seat))
+}
+
+// session looks up the game named in the path, writing a 404 if there is none
+func (s *APIServer) session(w http.ResponseWriter, r *http.Request) *Session {
+	session := s.find(r.PathValue("id"))
+	if session == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no game %q", r.PathValue("id")))
+	}
+	return session
+}
+
+// find returns the game with the given ID, or nil, and notes that it is
+// still in use
+func (s *APIServer) find(id string) *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session := s.sessions[id]
+	if session != nil {
+		s.used[id] = time.Now()
+	}
+	return session
+}
+
+// remove abandons a game and forgets it
+func (s *APIServer) remove(session *Session) {
+	session.Close()
+	s.mu.Lock()
+	delete(s.sessions, session.ID)
+	delete(s.used, session.ID)
+	s.mu.Unlock()
+}
+
+// expire removes a game nobody has asked about for the idle limit, or
+// checks again when it would run out
+func (s *APIServer) expire(session *Session) {
+	s.mu.Lock()
+	used, ok := s.used[session.ID]
+	s.mu.Unlock()
+	if !ok {
+		return // Already removed
+	}
+	if idle := time.Since(used); idle < s.idle {
+		time.AfterFunc(s.idle-idle, func() { s.expire(session) })
+		return
+	}
+	s.remove(session)
+}
+
+// seat looks up the game and a human seat named in the path
+func (s *APIServer) seat(w http.ResponseWriter, r *http.Request) (*Session, int) {
+	session := s.session(w, r)
+	if session == nil {
+		return nil, 0
+	}
+	seat, err := strconv.Atoi(r.PathValue("seat"))
+	if err != nil || seat < 0 || seat >= len(session.Remote) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no seat %q", r.PathValue("seat")))
+		return nil, 0
+	}
+	if !session.Remote[seat] {
+		writeError(w, http.StatusForbidden, fmt.Errorf("seat %d is played by the server", seat))
+		return nil, 0
+	}
+	return session, seat
+}
+
+// writeJSON sends a value with the given status
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError sends {"error": message}
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// RunAPIServer serves the HTTP API until the process is stopped
+func RunAPIServer(addr string) {
+	fmt.Printf("Serving the game API on http://%s/games\n", addr)
+	if err := http.ListenAndServe(addr, NewAPIServer()); err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+}
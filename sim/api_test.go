@@ -0,0 +1,337 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// apiView is the part of a PlayerView the tests read back. Cards and tokens
+// are only ever written as JSON, so they stay raw.
+type apiView struct {
+	Game       string            `json:"game"`
+	NumPlayers int               `json:"num_players"`
+	Board      []json.RawMessage `json:"board"`
+	Hand       []json.RawMessage `json:"hand"`
+	Decision   *Decision         `json:"decision"`
+	WaitingFor []int             `json:"waiting_for"`
+	Winner     int               `json:"winner"`
+}
+
+// apiCall sends a request to the server and decodes the JSON reply into out,
+// returning the status, or 0 after reporting an error. It may be called from
+// any goroutine.
+func apiCall(t *testing.T, server *httptest.Server, method, path string, body any, out any) int {
+	t.Helper()
+	var reader bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reader).Encode(body); err != nil {
+			t.Error(err)
+			return 0
+		}
+	}
+	req, err := http.NewRequest(method, server.URL+path, &reader)
+	if err != nil {
+		t.Error(err)
+		return 0
+	}
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Error(err)
+		return 0
+	}
+	defer resp.Body.Close()
+	if out != nil && resp.StatusCode < 300 && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Errorf("%s %s: %v", method, path, err)
+			return 0
+		}
+	}
+	return resp.StatusCode
+}
+
+// createGame starts a seeded game with the given seats over the API
+func createGame(t *testing.T, server *httptest.Server, agents ...string) apiView {
+	t.Helper()
+	seed := int64(1)
+	var view apiView
+	req := CreateGameRequest{Players: len(agents), Agents: agents, Seed: &seed}
+	if status := apiCall(t, server, "POST", "/games", req, &view); status != http.StatusCreated {
+		t.Fatalf("create returned %d", status)
+	}
+	return view
+}
+
+func TestAPICreateAndView(t *testing.T) {
+	server := httptest.NewServer(NewAPIServer())
+	defer server.Close()
+
+	created := createGame(t, server, humanSeat, "greedy")
+	if created.Winner != -1 || created.NumPlayers != 2 || created.Hand != nil {
+		t.Fatalf("unexpected public view after create: %+v", created)
+	}
+
+	var public apiView
+	if status := apiCall(t, server, "GET", "/games/"+created.Game, nil, &public); status != http.StatusOK {
+		t.Fatalf("public view returned %d", status)
+	}
+	if len(public.WaitingFor) != 1 || public.WaitingFor[0] != 0 {
+		t.Fatalf("expected the game to wait on seat 0, got %v", public.WaitingFor)
+	}
+
+	var seat apiView
+	if status := apiCall(t, server, "GET", "/games/"+created.Game+"/players/0", nil, &seat); status != http.StatusOK {
+		t.Fatalf("seat view returned %d", status)
+	}
+	if len(seat.Hand) != DefaultRules().HandSize || seat.Decision == nil {
+		t.Fatalf("seat view should hold a full hand and a decision: %+v", seat)
+	}
+
+	for path, want := range map[string]int{
+		"/games/nope":                           http.StatusNotFound,
+		"/games/" + created.Game + "/players/7": http.StatusNotFound,
+		"/games/" + created.Game + "/players/1": http.StatusForbidden,
+	} {
+		if status := apiCall(t, server, "GET", path, nil, nil); status != want {
+			t.Errorf("GET %s returned %d, want %d", path, status, want)
+		}
+	}
+}
+
+func TestAPIMoves(t *testing.T) {
+	server := httptest.NewServer(NewAPIServer())
+	defer server.Close()
+	id := createGame(t, server, humanSeat, humanSeat).Game
+
+	var public apiView
+	apiCall(t, server, "GET", "/games/"+id, nil, &public)
+	if len(public.WaitingFor) != 1 {
+		t.Fatalf("expected one seat to be drafting, got %v", public.WaitingFor)
+	}
+	seat := public.WaitingFor[0]
+	other := 1 - seat
+	movesPath := fmt.Sprintf("/games/%s/players/%d/moves", id, seat)
+
+	var decision Decision
+	if status := apiCall(t, server, "GET", movesPath, nil, &decision); status != http.StatusOK {
+		t.Fatalf("legal moves returned %d", status)
+	}
+	if decision.Kind != "draft" || len(decision.Moves) == 0 {
+		t.Fatalf("expected draft moves, got %+v", decision)
+	}
+
+	// The other seat has nothing to decide, and made-up moves are refused
+	var idle Decision
+	apiCall(t, server, "GET", fmt.Sprintf("/games/%s/players/%d/moves", id, other), nil, &idle)
+	if len(idle.Moves) != 0 {
+		t.Fatalf("seat %d should have no moves, got %+v", other, idle)
+	}
+	if status := apiCall(t, server, "POST", fmt.Sprintf("/games/%s/players/%d/moves", id, other), decision.Moves[0], nil); status != http.StatusConflict {
+		t.Errorf("move out of turn returned %d, want %d", status, http.StatusConflict)
+	}
+	illegal := Move{Kind: "draft", Attribute: "Nonsense", Value: "Nothing"}
+	if status := apiCall(t, server, "POST", movesPath, illegal, nil); status != http.StatusBadRequest {
+		t.Errorf("illegal move returned %d, want %d", status, http.StatusBadRequest)
+	}
+	if status := apiCall(t, server, "POST", movesPath, "not a move", nil); status != http.StatusBadRequest {
+		t.Errorf("malformed move returned %d, want %d", status, http.StatusBadRequest)
+	}
+
+	var after apiView
+	if status := apiCall(t, server, "POST", movesPath, decision.Moves[0], &after); status != http.StatusOK {
+		t.Fatalf("legal move returned %d", status)
+	}
+	placed := 0
+	for _, token := range after.Board {
+		if string(token) != "null" {
+			placed++
+		}
+	}
+	if placed != 1 {
+		t.Errorf("expected one token on the board after the first draft, got %d", placed)
+	}
+
+	if status := apiCall(t, server, "DELETE", "/games/"+id, nil, nil); status != http.StatusNoContent {
+		t.Errorf("delete returned %d", status)
+	}
+	if status := apiCall(t, server, "GET", "/games/"+id, nil, nil); status != http.StatusNotFound {
+		t.Errorf("deleted game returned %d", status)
+	}
+}
+
+// TestAPIConcurrentSeats plays a whole game with three clients submitting
+// their moves from separate goroutines. Run it with -race.
+func TestAPIConcurrentSeats(t *testing.T) {
+	server := httptest.NewServer(NewAPIServer())
+	defer server.Close()
+	id := createGame(t, server, humanSeat, humanSeat, humanSeat).Game
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+	for seat := 0; seat < 3; seat++ {
+		wg.Add(1)
+		go func(seat int) {
+			defer wg.Done()
+			path := fmt.Sprintf("/games/%s/players/%d", id, seat)
+			for deadline := time.Now().Add(time.Minute); time.Now().Before(deadline); {
+				var view apiView
+				if status := apiCall(t, server, "GET", path, nil, &view); status != http.StatusOK {
+					errs <- fmt.Errorf("seat %d view returned %d", seat, status)
+					return
+				}
+				if view.Winner != -1 {
+					return
+				}
+				if view.Decision == nil {
+					continue
+				}
+				// Another seat's move may have settled the game since the
+				// view; only a refused legal move is an error
+				status := apiCall(t, server, "POST", path+"/moves", view.Decision.Moves[0], nil)
+				if status != http.StatusOK && status != http.StatusConflict {
+					errs <- fmt.Errorf("seat %d move returned %d", seat, status)
+					return
+				}
+			}
+			errs <- fmt.Errorf("seat %d: the game did not finish", seat)
+		}(seat)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	var final apiView
+	apiCall(t, server, "GET", "/games/"+id, nil, &final)
+	if final.Winner == -1 {
+		t.Fatalf("the game is not over: %+v", final)
+	}
+}
+
+func TestAPIRejectsOversizedRules(t *testing.T) {
+	server := httptest.NewServer(NewAPIServer())
+	defer server.Close()
+
+	huge := DefaultRules()
+	for i := 0; i < MaxAttributes; i++ {
+		huge.Attributes = append(huge.Attributes, AttributeDef{
+			Name:   fmt.Sprintf("A%d", i),
+			Values: []string{"w", "x", "y", "z"},
+		})
+	}
+	longNames := DefaultRules()
+	longNames.Attributes = []AttributeDef{
+		{Name: strings.Repeat("a", 100), Values: []string{"x", "y"}},
+		{Name: "B", Values: []string{"x", "y"}},
+	}
+	endless := DefaultRules()
+	endless.TargetTricks = 1000000
+
+	for name, rules := range map[string]RuleConfig{"huge deck": huge, "long names": longNames, "endless": endless} {
+		req := CreateGameRequest{Players: 2, Rules: &rules}
+		if status := apiCall(t, server, "POST", "/games", req, nil); status != http.StatusBadRequest {
+			t.Errorf("%s: create returned %d, want %d", name, status, http.StatusBadRequest)
+		}
+	}
+}
+
+// TestAPIManyEditions creates games under many throwaway editions, which
+// must not stop later games from using another
+func TestAPIManyEditions(t *testing.T) {
+	server := httptest.NewServer(NewAPIServer())
+	defer server.Close()
+
+	for i := 0; i < 1100; i++ {
+		rules := DefaultRules()
+		rules.HandSize = 2
+		rules.Attributes = []AttributeDef{
+			{Name: fmt.Sprintf("A%d", i), Values: []string{"x", "y"}},
+			{Name: "B", Values: []string{"x", "y"}},
+		}
+		req := CreateGameRequest{Players: 2, Rules: &rules}
+		if status := apiCall(t, server, "POST", "/games", req, nil); status != http.StatusCreated {
+			t.Fatalf("game %d: create returned %d", i, status)
+		}
+	}
+	rules, err := LoadRules("rules/five-attributes.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := CreateGameRequest{Players: 2, Rules: &rules}
+	if status := apiCall(t, server, "POST", "/games", req, nil); status != http.StatusCreated {
+		t.Fatalf("five attributes: create returned %d", status)
+	}
+}
+
+func TestAPIForgetsIdleGames(t *testing.T) {
+	api := NewAPIServer()
+	api.idle = 50 * time.Millisecond
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	id := createGame(t, server, humanSeat, "greedy").Game
+	kept := time.Now().Add(api.idle)
+	for time.Now().Before(kept) {
+		if status := apiCall(t, server, "GET", "/games/"+id, nil, nil); status != http.StatusOK {
+			t.Fatalf("a game in use was forgotten: GET returned %d", status)
+		}
+		time.Sleep(api.idle / 5)
+	}
+	time.Sleep(3 * api.idle)
+	if status := apiCall(t, server, "GET", "/games/"+id, nil, nil); status != http.StatusNotFound {
+		t.Fatalf("an idle game was kept: GET returned %d", status)
+	}
+}
+
+// TestAPIAuction plays a card auction with both seats over the API, always
+// taking the last legal move so that bids are as high as allowed and the
+// winners must discard
+func TestAPIAuction(t *testing.T) {
+	server := httptest.NewServer(NewAPIServer())
+	defer server.Close()
+	seed := int64(1)
+	rules := DefaultRules()
+	rules.Auction = AuctionCards
+	var created apiView
+	req := CreateGameRequest{Players: 2, Agents: []string{humanSeat, humanSeat}, Rules: &rules, Seed: &seed}
+	if status := apiCall(t, server, "POST", "/games", req, &created); status != http.StatusCreated {
+		t.Fatalf("create returned %d", status)
+	}
+
+	kinds := make(map[string]bool)
+	for moves := 0; moves < 10000; moves++ {
+		var public apiView
+		apiCall(t, server, "GET", "/games/"+created.Game, nil, &public)
+		if public.Winner != -1 {
+			break
+		}
+		if len(public.WaitingFor) == 0 {
+			t.Fatalf("the game waits on nobody: %+v", public)
+		}
+		path := fmt.Sprintf("/games/%s/players/%d", created.Game, public.WaitingFor[0])
+		var view apiView
+		apiCall(t, server, "GET", path, nil, &view)
+		kinds[view.Decision.Kind] = true
+		move := view.Decision.Moves[len(view.Decision.Moves)-1]
+		if status := apiCall(t, server, "POST", path+"/moves", move, nil); status != http.StatusOK {
+			t.Fatalf("%s move returned %d", view.Decision.Kind, status)
+		}
+	}
+	var final apiView
+	apiCall(t, server, "GET", "/games/"+created.Game, nil, &final)
+	if final.Winner == -1 {
+		t.Fatalf("the game is not over: %+v", final)
+	}
+	for _, kind := range []string{"bid", "discard", "draft", "present"} {
+		if !kinds[kind] {
+			t.Errorf("no %s decision was offered", kind)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+//go:generate go run copyweb.go
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// webPage is the web app's entry point within the web assets
+const webPage = "eye-of-the-beeholder.html"
+
+// embeddedWeb holds the web app when the binary is built with the embedweb
+// tag (see assets_embed.go), and is nil otherwise
+var embeddedWeb fs.FS
+
+// webAssets returns the web app to serve: the embedded copy if there is one,
+// otherwise the directory dir on disk
+func webAssets(dir string) (fs.FS, error) {
+	if embeddedWeb != nil && dir == "" {
+		return embeddedWeb, nil
+	}
+	if dir == "" {
+		dir = "../web"
+	}
+	assets := os.DirFS(dir)
+	if _, err := fs.Stat(assets, webPage); err != nil {
+		return nil, fmt.Errorf("no web app in %s: %v", dir, err)
+	}
+	return assets, nil
+}
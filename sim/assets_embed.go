@@ -0,0 +1,27 @@
+//go:build embedweb
+
+package main
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// Build a self-contained binary with
+//
+//	go generate
+//	go build -tags embedweb
+//
+// go generate copies ../web into this directory with copyweb.go, since
+// embed cannot reach outside the module.
+
+//go:embed web
+var webFiles embed.FS
+
+func init() {
+	assets, err := fs.Sub(webFiles, "web")
+	if err != nil {
+		panic(err)
+	}
+	embeddedWeb = assets
+}
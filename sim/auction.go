@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Ways to pay for a slot in an auction draft, set by RuleConfig.Auction
+const (
+	AuctionPoints = "points" // Bids are paid from the score: tricks already won, or points under other scoring
+	AuctionCards  = "cards"  // Bids are paid by discarding bees from hand to The Box
+)
+
+// Bidder is implemented by agents that can play an auction draft. Seats
+// whose agents are not Bidders bid like BaselineBidder.
+type Bidder interface {
+	// ChooseBid returns the player's sealed bid for slotIdx, from 0 to maxBid
+	ChooseBid(g *Game, player *Player, availableTokens []Attribute, slotIdx int, maxBid int) int
+
+	// ChooseDiscards returns the indices in player.Hand of the n cards that
+	// pay for a slot won with a bid of n cards
+	ChooseDiscards(g *Game, player *Player, n int) []int
+}
+
+// BaselineBidder bids what a slot is worth: the share of tricks it decides,
+// times how lopsided the player's hand is in the attribute they would place
+// there, times the tricks left to play with that hand. Cards are cheaper
+// than tricks, so it offers twice as many of them. It pays card bids with
+// the bees that match the board least.
+type BaselineBidder struct{}
+
+func (BaselineBidder) ChooseBid(g *Game, player *Player, availableTokens []Attribute, slotIdx int, maxBid int) int {
+	if maxBid == 0 || len(player.Hand) == 0 {
+		return 0
+	}
+	edition := g.Edition()
+	attr, _ := g.selectBestToken(player, availableTokens, slotIdx)
+	counts := valueCounts(player.Hand, attr, edition.NumValues(attr))
+	most, _ := extremeValues(counts)
+
+	// How far the hand leans from an even split: 0 for an even hand, nearly
+	// 1 when every bee shares a value
+	edge := float64(counts[most])/float64(len(player.Hand)) - 1/float64(len(counts))
+	share := g.slotShare(slotIdx)
+	worth := edge * share * float64(len(player.Hand))
+
+	bid := int(worth) // Paying a whole trick for less than one is a loss
+	if g.Rules.Auction == AuctionCards {
+		bid = int(math.Round(2 * worth))
+	}
+	return min(bid, maxBid)
+}
+
+func (BaselineBidder) ChooseDiscards(g *Game, player *Player, n int) []int {
+	order := make([]int, len(player.Hand))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return g.scoreCard(player.Hand[order[i]]) < g.scoreCard(player.Hand[order[j]])
+	})
+	return order[:n]
+}
+
+// bidder returns the seat's Bidder, or BaselineBidder if its agent is not one
+func (g *Game) bidder(playerID int) Bidder {
+	if b, ok := g.agent(playerID).(Bidder); ok {
+		return b
+	}
+	return BaselineBidder{}
+}
+
+// maxBid is the most a player can pay: their whole score, or all but one of
+// their bees so that they can still present a card
+func (g *Game) maxBid(player *Player) int {
+	if g.Rules.Auction == AuctionCards {
+		return max(len(player.Hand)-1, 0)
+	}
+	return player.Score
+}
+
+// auctionUnit names what n bids pay with, such as "2 tricks"
+func (g *Game) auctionUnit(n int) string {
+	unit := "trick"
+	switch {
+	case g.Rules.Auction == AuctionCards:
+		unit = "card"
+	case g.Rules.ScoringName() != "tricks":
+		unit = "point"
+	}
+	if n != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%d %s", n, unit)
+}
+
+// RunAuctionPhase fills the Queen's Favor by auction instead of a draft
+// schedule. The slots are sold one at a time from the last up to Slot 1.
+// Every player makes a sealed bid; the highest pays it and places a tile.
+// Ties go to the tied player who has won the fewest slots this hand, then to
+// the earliest in ccw, the counter-clockwise order of a standard draft.
+func (g *Game) RunAuctionPhase(ccw []int, availableTokens *[]Attribute) {
+	won := make([]int, g.NumPlayers)
+	for slotIdx := len(g.Board.Slots) - 1; slotIdx >= 0 && len(*availableTokens) > 0; slotIdx-- {
+		bids := make([]int, g.NumPlayers)
+		winner := -1
+		for _, playerID := range ccw {
+			player := g.Players[playerID]
+			maxBid := g.maxBid(player)
+			bid := g.bidder(playerID).ChooseBid(g, player, *availableTokens, slotIdx, maxBid)
+			bids[playerID] = max(0, min(bid, maxBid))
+
+			if winner == -1 || bids[playerID] > bids[winner] ||
+				(bids[playerID] == bids[winner] && won[playerID] < won[winner]) {
+				winner = playerID
+			}
+		}
+		won[winner]++
+
+		price := bids[winner]
+		g.payBid(g.Players[winner], price)
+		if g.Verbose {
+			fmt.Printf("Player %d wins Slot %d for %s (bids %v)\n", winner, slotIdx+1, g.auctionUnit(price), bids)
+		}
+		g.emit(Event{Kind: EventBid, PlayerID: winner, SlotIndex: slotIdx, Bids: bids})
+
+		if g.Stats != nil {
+			g.Stats.SlotsAuctioned++
+			g.Stats.BidsPaid += price
+			if price == 0 {
+				g.Stats.FreeSlots++
+			}
+			if slotIdx == 0 {
+				g.Stats.Slot1Paid += price
+			}
+		}
+
+		g.draftTokens(winner, availableTokens, []int{slotIdx})
+	}
+}
+
+// payBid takes the price of a slot from the player: points off their score,
+// or bees of their choosing from their hand into The Box
+func (g *Game) payBid(player *Player, price int) {
+	if g.Rules.Auction != AuctionCards {
+		player.Score -= price
+		return
+	}
+	if price == 0 {
+		return
+	}
+
+	discards := g.bidder(player.ID).ChooseDiscards(g, player, price)
+	chosen := make(map[int]bool)
+	for _, idx := range discards {
+		if idx >= 0 && idx < len(player.Hand) {
+			chosen[idx] = true
+		}
+	}
+	if len(discards) != price || len(chosen) != price {
+		// Not price distinct bees from the hand
+		chosen = make(map[int]bool)
+		for _, idx := range (BaselineBidder{}).ChooseDiscards(g, player, price) {
+			chosen[idx] = true
+		}
+	}
+
+	kept := make([]Card, 0, len(player.Hand)-price)
+	for i, card := range player.Hand {
+		if chosen[i] {
+			g.Box = append(g.Box, card)
+		} else {
+			kept = append(kept, card)
+		}
+	}
+	player.Hand = kept
+}
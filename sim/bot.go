@@ -0,0 +1,484 @@
+package main
+
+// External bots play through a line-based protocol on their standard input
+// and output, in the spirit of UCI for chess engines. All indices are
+// 0-based: attributes follow the edition's order (the Attribute constants
+// for the printed game), values index the attribute's value names (0 or 1
+// for two-sided tiles), and slot 0 is Slot 1.
+//
+// Setup, once per game:
+//
+//	engine: bee
+//	bot:    id name <name>          (optional)
+//	bot:    beeok
+//	engine: newgame <num_players> <seat>
+//	engine: attribute <name> <value> ...   (one per attribute, in order)
+//
+// The attribute lines give the edition: every attribute's name and the
+// names of its values, value 0 first, with any spaces written as "_".
+//
+// Before every decision the engine describes the public state and the bot's
+// hand. Cards are one value digit per attribute (six in the printed game),
+// in attribute order; tokens are <attribute>:<value>, or "-" for an empty
+// slot.
+// The board has a slot per attribute.
+//
+//	engine: state <hand_number> <trick_number> <leader> <sudden_death 0|1>
+//	engine: scores <each player's score: tricks won, unless the rules score otherwise>
+//	engine: board <slot 0> ... <last slot>
+//	engine: hand <card> ...
+//	engine: revealed <card> ...     (cards presented so far this hand)
+//	engine: actions <kind> ...      (only if the rules allow manipulations besides flip and swap)
+//	engine: auction <points|cards>  (only if the slots are auctioned rather than drafted)
+//	engine: seen <player> <card>    (one per bee seen by peeking and not yet presented)
+//	engine: norepeat <rule>         (only if the rules limit repeated manipulations other than by forbidding copies)
+//	engine: made <player> <action>  (one per manipulation made so far this round, in order)
+//
+// Then it asks for one decision:
+//
+//	engine: draft <slot> <available attribute> ...   bot: token <attribute> <value>
+//	engine: present                                 bot: card <hand index>
+//	engine: manipulate [<forbidden action>]          bot: flip <slot> | swap <slot> <slot>
+//
+// Rules may allow other manipulations, which take their numbers the same
+// way: rotate, lock <slot>, pass and peek <player>. Under the published
+// no-repeat rule the forbidden action, written the same way as the reply,
+// is the previous player's manipulation, which may not be copied. Other
+// rules (see RuleConfig.NoRepeat) forbid more, so before every manipulate
+// request the engine lists the manipulations the bot may make:
+//
+//	engine: legal <action>          (one per legal manipulation)
+//
+// In an auction the slots are sold instead of drafted. The engine asks for
+// a sealed bid of up to <max> tricks or cards for the slot, and when a card
+// bid wins, for that many distinct bees from the hand to pay with:
+//
+//	engine: bid <slot> <max> <available attribute> ...   bot: bid <amount>
+//	engine: discard <n>                                 bot: discard <hand index> ...
+//
+// Lines starting with "info" are ignored, or shown next to the AI reasoning
+// in verbose games. When the game is over the engine sends "gameover
+// <winner>" and "quit".
+//
+// A bot that fails to start, exits, misses the time limit or replies with an
+// illegal move forfeits the game.
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultBotTimeLimit = time.Second
+	botStartupLimit     = 5 * time.Second // Allowance for "beeok", since interpreters can be slow to start
+	botShutdownLimit    = time.Second     // Grace after "quit" before the process is killed
+)
+
+// BotAgent plays a seat through an external program. Every game launches a
+// fresh process, started when the bot's first decision is needed.
+type BotAgent struct {
+	Command   []string
+	TimeLimit time.Duration // Per decision
+	Name      string        // Reported by the bot during setup
+
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	lines  chan string // Closed when the bot's output ends
+	failed bool        // Forfeited; moves are made greedily until the game ends
+}
+
+func (b *BotAgent) ChooseToken(g *Game, player *Player, availableTokens []Attribute, slotIdx int) (Attribute, int) {
+	request := fmt.Sprintf("draft %d", slotIdx)
+	for _, attr := range availableTokens {
+		request += fmt.Sprintf(" %d", attr)
+	}
+
+	if fields, ok := b.ask(g, player, request, "token"); ok {
+		attr, errA := strconv.Atoi(fields[0])
+		value, errV := strconv.Atoi(fields[len(fields)-1])
+		if len(fields) == 2 && errA == nil && errV == nil {
+			for _, available := range availableTokens {
+				if Attribute(attr) == available && value >= 0 && value < g.Edition().NumValues(available) {
+					return available, value
+				}
+			}
+		}
+		b.fail(g, player, fmt.Sprintf("illegal draft %q", strings.Join(fields, " ")))
+	}
+	return GreedyAgent{}.ChooseToken(g, player, availableTokens, slotIdx)
+}
+
+func (b *BotAgent) ChooseCard(g *Game, player *Player) int {
+	if fields, ok := b.ask(g, player, "present", "card"); ok {
+		idx, err := strconv.Atoi(fields[0])
+		if len(fields) == 1 && err == nil && idx >= 0 && idx < len(player.Hand) {
+			return idx
+		}
+		b.fail(g, player, fmt.Sprintf("illegal card %q", strings.Join(fields, " ")))
+	}
+	return GreedyAgent{}.ChooseCard(g, player)
+}
+
+func (b *BotAgent) ChooseAction(g *Game, player *Player, previousAction *Action) Action {
+	legal := g.legalActions(player, previousAction)
+	lines := []string{}
+	for _, action := range legal {
+		lines = append(lines, "legal "+botActionString(action))
+	}
+	request := "manipulate"
+	if previousAction != nil && g.Rules.NoRepeatRule() == NoRepeatPrevious {
+		request += " " + botActionString(*previousAction)
+	}
+	request = strings.Join(append(lines, request), "\n")
+
+	if fields, ok := b.ask(g, player, request, g.Rules.ActionNames()...); ok {
+		if action, err := parseBotAction(fields, g); err == nil {
+			for _, legal := range legal {
+				if actionsMatch(legal, action) {
+					return legal
+				}
+			}
+		}
+		b.fail(g, player, fmt.Sprintf("illegal manipulation %q", strings.Join(fields, " ")))
+	}
+	return GreedyAgent{}.ChooseAction(g, player, previousAction)
+}
+
+func (b *BotAgent) ChooseBid(g *Game, player *Player, availableTokens []Attribute, slotIdx int, maxBid int) int {
+	request := fmt.Sprintf("bid %d %d", slotIdx, maxBid)
+	for _, attr := range availableTokens {
+		request += fmt.Sprintf(" %d", attr)
+	}
+
+	if fields, ok := b.ask(g, player, request, "bid"); ok {
+		bid, err := strconv.Atoi(fields[0])
+		if len(fields) == 1 && err == nil && bid >= 0 && bid <= maxBid {
+			return bid
+		}
+		b.fail(g, player, fmt.Sprintf("illegal bid %q", strings.Join(fields, " ")))
+	}
+	return BaselineBidder{}.ChooseBid(g, player, availableTokens, slotIdx, maxBid)
+}
+
+func (b *BotAgent) ChooseDiscards(g *Game, player *Player, n int) []int {
+	if fields, ok := b.ask(g, player, fmt.Sprintf("discard %d", n), "discard"); ok {
+		discards := []int{}
+		for _, field := range fields {
+			idx, err := strconv.Atoi(field)
+			if err != nil || idx < 0 || idx >= len(player.Hand) || slices.Contains(discards, idx) {
+				break
+			}
+			discards = append(discards, idx)
+		}
+		if len(discards) == n && len(fields) == n {
+			return discards
+		}
+		b.fail(g, player, fmt.Sprintf("illegal discards %q", strings.Join(fields, " ")))
+	}
+	return BaselineBidder{}.ChooseDiscards(g, player, n)
+}
+
+// EndGame tells the bot the result and shuts it down
+func (b *BotAgent) EndGame(g *Game, winner int) {
+	if b.cmd == nil || b.failed {
+		return
+	}
+	b.send("gameover %d", winner)
+	b.send("quit")
+	b.stop()
+}
+
+// ask sends the state and a request, whose own lines such as the legal
+// manipulations come before it, and returns the reply without its keyword.
+// The reply must start with one of the expected keywords; on any failure the
+// bot forfeits and ask returns false.
+func (b *BotAgent) ask(g *Game, player *Player, request string, expected ...string) ([]string, bool) {
+	if b.failed {
+		return nil, false
+	}
+	if b.cmd == nil {
+		if err := b.start(g, player); err != nil {
+			b.fail(g, player, err.Error())
+			return nil, false
+		}
+	}
+
+	if err := b.sendState(g, player); err != nil {
+		b.fail(g, player, err.Error())
+		return nil, false
+	}
+	if err := b.send("%s", request); err != nil {
+		b.fail(g, player, err.Error())
+		return nil, false
+	}
+
+	reply, err := b.receive(g, player, b.TimeLimit)
+	if err != nil {
+		b.fail(g, player, err.Error())
+		return nil, false
+	}
+	fields := strings.Fields(reply)
+	for _, keyword := range expected {
+		if len(fields) == 0 || fields[0] != keyword {
+			continue
+		}
+		if _, ok := actionKinds[keyword]; ok {
+			return fields, true // parseBotAction reads the keyword too
+		}
+		if len(fields) > 1 {
+			return fields[1:], true
+		}
+	}
+	b.fail(g, player, fmt.Sprintf("expected %s, got %q", strings.Join(expected, " or "), reply))
+	return nil, false
+}
+
+// start launches the bot and performs the setup handshake
+func (b *BotAgent) start(g *Game, player *Player) error {
+	b.cmd = exec.Command(b.Command[0], b.Command[1:]...)
+	b.cmd.Stderr = os.Stderr
+	stdin, err := b.cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := b.cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := b.cmd.Start(); err != nil {
+		return fmt.Errorf("cannot start bot: %v", err)
+	}
+	b.stdin = stdin
+
+	b.lines = make(chan string, 16)
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			b.lines <- strings.TrimSpace(scanner.Text())
+		}
+		close(b.lines)
+	}()
+
+	if err := b.send("bee"); err != nil {
+		return err
+	}
+	for {
+		line, err := b.receive(g, player, max(b.TimeLimit, botStartupLimit))
+		if err != nil {
+			return err
+		}
+		if name, ok := strings.CutPrefix(line, "id name "); ok {
+			b.Name = name
+		} else if line == "beeok" {
+			break
+		}
+	}
+	if err := b.send("newgame %d %d", g.NumPlayers, player.ID); err != nil {
+		return err
+	}
+	edition := g.Edition()
+	for _, attr := range edition.AllAttributes() {
+		names := []string{botName(edition.AttributeName(attr))}
+		for value := range edition.NumValues(attr) {
+			names = append(names, botName(edition.ValueName(attr, value)))
+		}
+		if err := b.send("attribute %s", strings.Join(names, " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// botName writes a name as one word, with its spaces as "_"
+func botName(name string) string {
+	return strings.Join(strings.Fields(name), "_")
+}
+
+// sendState describes the game as the player sees it
+func (b *BotAgent) sendState(g *Game, player *Player) error {
+	suddenDeath := 0
+	if g.SuddenDeath {
+		suddenDeath = 1
+	}
+	scores := make([]string, len(g.Players))
+	for i, p := range g.Players {
+		scores[i] = strconv.Itoa(p.Score)
+	}
+	slots := make([]string, len(g.Board.Slots))
+	for i, token := range g.Board.Slots {
+		slots[i] = "-"
+		if token != nil {
+			slots[i] = fmt.Sprintf("%d:%d", token.Attribute, token.Value)
+		}
+	}
+
+	lines := []string{
+		fmt.Sprintf("state %d %d %d %d", g.HandNumber, g.TrickNumber, g.CurrentLeader, suddenDeath),
+		"scores " + strings.Join(scores, " "),
+		"board " + strings.Join(slots, " "),
+		"hand " + botCards(player.Hand),
+		"revealed " + botCards(g.Revealed),
+	}
+	if g.Rules.Actions != nil {
+		lines = append(lines, "actions "+strings.Join(g.Rules.Actions, " "))
+	}
+	if g.Rules.Auction != "" {
+		lines = append(lines, "auction "+g.Rules.Auction)
+	}
+	for _, peek := range player.knownHeld() {
+		lines = append(lines, fmt.Sprintf("seen %d %s", peek.Holder, cardCode(peek.Card)))
+	}
+	if rule := g.Rules.NoRepeatRule(); rule != NoRepeatPrevious {
+		lines = append(lines, "norepeat "+rule)
+	}
+	for _, m := range g.Manipulations {
+		lines = append(lines, fmt.Sprintf("made %d %s", m.PlayerID, botActionString(m.Action)))
+	}
+	for _, line := range lines {
+		if err := b.send("%s", strings.TrimSpace(line)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// send writes one line to the bot
+func (b *BotAgent) send(format string, args ...any) error {
+	if _, err := fmt.Fprintf(b.stdin, format+"\n", args...); err != nil {
+		return fmt.Errorf("bot stopped reading: %v", err)
+	}
+	return nil
+}
+
+// receive waits for the next line that is not info, up to the time limit
+func (b *BotAgent) receive(g *Game, player *Player, limit time.Duration) (string, error) {
+	timer := time.NewTimer(limit)
+	defer timer.Stop()
+	for {
+		select {
+		case line, ok := <-b.lines:
+			if !ok {
+				return "", fmt.Errorf("bot exited")
+			}
+			if info, isInfo := strings.CutPrefix(line, "info"); isInfo {
+				if g.showReasoning() {
+					fmt.Printf("  (Player %d bot:%s)\n", player.ID, info)
+				}
+				continue
+			}
+			if line != "" {
+				return line, nil
+			}
+		case <-timer.C:
+			return "", fmt.Errorf("no reply within %v", limit)
+		}
+	}
+}
+
+// fail forfeits the game and shuts the bot down
+func (b *BotAgent) fail(g *Game, player *Player, reason string) {
+	b.failed = true
+	g.Forfeit(player.ID, reason)
+	b.stop()
+}
+
+// stop closes the bot's input and kills it if it does not exit promptly
+func (b *BotAgent) stop() {
+	if b.cmd == nil || b.cmd.Process == nil {
+		return
+	}
+	if b.stdin != nil {
+		b.stdin.Close()
+	}
+	go func(lines chan string) {
+		for range lines {
+			// Unblock the reader so it can finish
+		}
+	}(b.lines)
+	done := make(chan struct{})
+	go func() {
+		b.cmd.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(botShutdownLimit):
+		b.cmd.Process.Kill()
+		<-done
+	}
+	b.cmd.Process = nil
+}
+
+// botCards encodes cards as a value digit per attribute each
+func botCards(cards []Card) string {
+	codes := make([]string, len(cards))
+	for i, card := range cards {
+		codes[i] = cardCode(card)
+	}
+	return strings.Join(codes, " ")
+}
+
+// cardCode encodes a card as a value digit per attribute, in attribute order
+func cardCode(card Card) string {
+	var code strings.Builder
+	for _, value := range card.Attributes[:editionOf(card.Edition).NumAttributes()] {
+		code.WriteString(strconv.Itoa(value))
+	}
+	return code.String()
+}
+
+// botActionString writes an action the way bots do, such as "swap 1 4"
+func botActionString(a Action) string {
+	parts := []string{a.Type}
+	for _, arg := range actionKinds[a.Type].Args(a) {
+		parts = append(parts, strconv.Itoa(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseBotAction reads an action such as "flip <slot>" or "swap <slot> <slot>"
+func parseBotAction(fields []string, g *Game) (Action, error) {
+	kind := actionKinds[fields[0]]
+	if len(fields)-1 != len(kind.Params()) {
+		return Action{}, fmt.Errorf("malformed action")
+	}
+	args := []int{}
+	for i, param := range kind.Params() {
+		limit := len(g.Board.Slots)
+		if param == "player" {
+			limit = g.NumPlayers
+		}
+		n, err := strconv.Atoi(fields[i+1])
+		if err != nil || n < 0 || n >= limit {
+			return Action{}, fmt.Errorf("%ss go from 0 to %d", param, limit-1)
+		}
+		args = append(args, n)
+	}
+	return kind.Build(args), nil
+}
+
+// parseBotAgent handles "bot:[<time_limit_ms>:]<command line>"
+func parseBotAgent(arg string) (AgentFactory, error) {
+	limit := defaultBotTimeLimit
+	if ms, rest, ok := strings.Cut(arg, ":"); ok {
+		if n, err := strconv.Atoi(ms); err == nil {
+			if n < 1 {
+				return nil, fmt.Errorf("invalid bot time limit %q", ms)
+			}
+			limit = time.Duration(n) * time.Millisecond
+			arg = rest
+		}
+	}
+	command := strings.Fields(arg)
+	if len(command) == 0 {
+		return nil, fmt.Errorf("bot agent needs bot:[<time_limit_ms>:]<command>")
+	}
+	return func() Agent { return &BotAgent{Command: command, TimeLimit: limit} }, nil
+}
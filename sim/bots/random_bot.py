@@ -0,0 +1,57 @@
+#!/usr/bin/env python3
+"""Example bot for the engine's stdin/stdout protocol (see sim/bot.go).
+
+Plays uniformly random legal moves. Run it with:
+
+    go run . tournament 4 100 greedy "bot:python3 bots/random_bot.py"
+"""
+
+import random
+import sys
+
+
+def reply(line):
+    print(line, flush=True)
+
+
+def main():
+    hand = []
+    legal = []  # Manipulations allowed in the coming request
+    values = []  # Number of values of each attribute
+    for line in sys.stdin:
+        fields = line.split()
+        if not fields:
+            continue
+        command, args = fields[0], fields[1:]
+
+        if command == "bee":
+            reply("id name random")
+            reply("beeok")
+        elif command == "newgame":
+            values = []
+        elif command == "attribute":
+            values.append(len(args) - 1)
+        elif command == "state":
+            legal = []
+        elif command == "legal":
+            legal.append(" ".join(args))
+        elif command == "hand":
+            hand = args
+        elif command == "draft":
+            attr = int(random.choice(args[1:]))
+            reply("token %d %d" % (attr, random.randrange(values[attr])))
+        elif command == "bid":
+            reply("bid %d" % random.randint(0, int(args[1])))
+        elif command == "discard":
+            discards = random.sample(range(len(hand)), int(args[0]))
+            reply("discard " + " ".join(str(i) for i in discards))
+        elif command == "present":
+            reply("card %d" % random.randrange(len(hand)))
+        elif command == "manipulate":
+            reply(random.choice(legal))
+        elif command == "quit":
+            break
+
+
+if __name__ == "__main__":
+    main()
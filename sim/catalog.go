@@ -0,0 +1,113 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// defaultCatalog is the printed deck, taken from CARD_DATA_RAW in the web app
+//
+//go:embed cards.json
+var defaultCatalog []byte
+
+// CardInfo identifies a physical card: its number, the bee's name and the
+// art for it in the web app
+type CardInfo struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	Attributes []int  `json:"attributes"` // 0 or 1 per attribute, in attribute order
+	Image      string `json:"image"`      // Relative to web/
+}
+
+// Catalog maps each combination of attributes to its card. Catalogs describe
+// the printed edition; cards of other editions have no names.
+type Catalog map[Card]CardInfo
+
+// cardCatalog names the cards in every game; replaced by the --cards flag
+var cardCatalog = mustParseCatalog(defaultCatalog)
+
+// ParseCatalog reads a catalog from a JSON array of CardInfo. Every card in
+// the deck must appear exactly once, with an ID of its own.
+func ParseCatalog(data []byte) (Catalog, error) {
+	var infos []CardInfo
+	if err := json.Unmarshal(data, &infos); err != nil {
+		return nil, fmt.Errorf("invalid card catalog: %v", err)
+	}
+	catalog := make(Catalog)
+	ids := make(map[int]string)
+	for _, info := range infos {
+		if info.Name == "" {
+			return nil, fmt.Errorf("card %d has no name", info.ID)
+		}
+		if other, ok := ids[info.ID]; ok {
+			return nil, fmt.Errorf("%s and %s are both card %d", other, info.Name, info.ID)
+		}
+		ids[info.ID] = info.Name
+
+		if len(info.Attributes) != printedEdition.NumAttributes() {
+			return nil, fmt.Errorf("%s has %d attributes instead of %d", info.Name, len(info.Attributes), printedEdition.NumAttributes())
+		}
+		card := Card{Edition: printedEdition}
+		for i, value := range info.Attributes {
+			if value != 0 && value != 1 {
+				return nil, fmt.Errorf("%s has %s value %d; values are 0 or 1", info.Name, printedEdition.AttributeName(Attribute(i)), value)
+			}
+			card.Attributes[i] = value
+		}
+		if other, ok := catalog[card]; ok {
+			return nil, fmt.Errorf("%s and %s are both %s", other.Name, info.Name, card.Values())
+		}
+		catalog[card] = info
+	}
+	for _, card := range CreateDeck(printedEdition) {
+		if _, ok := catalog[card]; !ok {
+			return nil, fmt.Errorf("no card in the catalog is %s", card.Values())
+		}
+	}
+	return catalog, nil
+}
+
+// LoadCatalog reads a catalog from a file
+func LoadCatalog(path string) (Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	catalog, err := ParseCatalog(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return catalog, nil
+}
+
+// mustParseCatalog parses the built-in catalog, which is known to be valid
+func mustParseCatalog(data []byte) Catalog {
+	catalog, err := ParseCatalog(data)
+	if err != nil {
+		panic(err)
+	}
+	return catalog
+}
+
+// Info returns the card's entry in the catalog
+func (c Card) Info() (CardInfo, bool) {
+	info, ok := cardCatalog[c]
+	return info, ok
+}
+
+// Name returns the bee's name, or "" if the catalog does not have the card
+func (c Card) Name() string {
+	return cardCatalog[c].Name
+}
+
+// cardNumber labels the card with its printed number, such as "#05", or ""
+// if the catalog does not have it
+func cardNumber(c Card) string {
+	info, ok := c.Info()
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("#%02d", info.ID)
+}
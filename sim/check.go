@@ -0,0 +1,365 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// checkInvariants attaches an InvariantChecker to every new game; set by
+// the --check flag
+var checkInvariants bool
+
+// InvariantChecker is an observer that validates the game state after every
+// phase and panics on the first broken invariant:
+//
+//   - every card of the edition is present once for each deck across the
+//     deck, hands, The Box, score piles and the trick being judged
+//   - no attribute is on the board twice, and once drafting is over every
+//     attribute is on it exactly once
+//   - the players' TricksWon add up to the number of tricks won, which is
+//     every trick played but those split under the split tie rule, and
+//     their scores to the points those tricks and captured bees scored, less
+//     any spent in auctions
+//   - no points a split tie left over are still in the pot when the game
+//     ends
+//   - no manipulation breaks the no-repeat rule or moves a locked tile
+//   - every player holds the same number of cards, unless they have paid
+//     for slots with them or presented more to settle ties
+type InvariantChecker struct {
+	Seed int64
+
+	tricks  int
+	points  int                    // Points scored for tricks and captured bees
+	pot     int                    // Points the last trick left for the next
+	spent   int                    // Points paid for slots in auctions
+	inPlay  []Card                 // Cards presented this trick, out of the hands but not yet in a score pile
+	board   ProtocolBoard          // The board after the last event
+	history []Manipulation         // Manipulations this round
+	locked  map[int]AttributeToken // Locked slots' tokens this round
+}
+
+func (c *InvariantChecker) Observe(g *Game, e Event) {
+	awarded, scored := c.tricks, c.points // Already added to TricksWon and Score
+	switch e.Kind {
+	case EventHandEnd:
+		// Observed after the captured bees are scored
+		for _, p := range g.Players {
+			c.points += g.scoring().HandPoints(g, p)
+		}
+		scored = c.points
+	case EventBid:
+		if g.Rules.Auction == AuctionPoints {
+			c.spent += e.Bids[e.PlayerID]
+		}
+	case EventReveal:
+		c.inPlay = nil
+		for _, play := range e.Plays {
+			c.inPlay = append(c.inPlay, play.Card)
+		}
+		c.history = nil
+		c.locked = make(map[int]AttributeToken)
+	case EventReplay:
+		for _, play := range e.Plays {
+			c.inPlay = append(c.inPlay, play.Card)
+		}
+	case EventTrickWon:
+		// Observed before the trick is awarded; a split tie may leave some
+		// of its points for the next trick
+		if e.Shares == nil {
+			c.tricks++
+		}
+		c.pot = e.Points
+		for _, p := range g.Players {
+			c.points += e.pointsFor(p.ID)
+			c.pot -= e.pointsFor(p.ID)
+		}
+	case EventGameOver:
+		// Observed once any pot has been settled
+		if g.Pot != 0 {
+			c.fail(g, e, "the game ended with %d points in the pot", g.Pot)
+		}
+		c.points += c.pot
+		scored = c.points
+	case EventAction:
+		if e.Cancelled {
+			if !sameBoard(c.board, g.Board) {
+				c.fail(g, e, "player %d's cancelled manipulation changed the board (%s)", e.PlayerID, e.Action)
+			}
+			break
+		}
+		rule := g.Rules.NoRepeatRule()
+		if repeatForbidden(rule, c.history, c.board, *e.Action) {
+			c.fail(g, e, "player %d broke the %q no-repeat rule (%s)", e.PlayerID, rule, e.Action)
+		}
+		c.history = append(c.history, Manipulation{PlayerID: e.PlayerID, Action: *e.Action, Before: c.board, After: g.Board.clone()})
+		for slot, token := range c.locked {
+			if *g.Board.Slots[slot] != token {
+				c.fail(g, e, "player %d changed Slot %d while it was locked (%s)", e.PlayerID, slot+1, e.Action)
+			}
+		}
+		for slot, locked := range g.Board.Locked {
+			if locked {
+				c.locked[slot] = *g.Board.Slots[slot]
+			}
+		}
+	}
+
+	if e.Kind != EventJudgeSlot && e.Kind != EventJudgeTotals && e.Kind != EventTrickWon && e.Kind != EventReveal && e.Kind != EventReplay {
+		// The trick has been awarded, so its cards are in a score pile
+		c.inPlay = nil
+	}
+	c.checkCards(g, e)
+	c.checkBoard(g, e)
+	c.checkHands(g, e)
+	c.board = g.Board.clone()
+
+	tricks, score := 0, 0
+	for _, p := range g.Players {
+		tricks += p.TricksWon
+		score += p.Score
+	}
+	if tricks != awarded {
+		c.fail(g, e, "players hold %d tricks in total but %d have been won", tricks, awarded)
+	}
+	if score != scored-c.spent {
+		c.fail(g, e, "players' scores add up to %d but %d points have been scored and %d spent", score, scored, c.spent)
+	}
+}
+
+// checkCards verifies that every card is somewhere, once for each deck
+func (c *InvariantChecker) checkCards(g *Game, e Event) {
+	seen := make(map[Card][]string)
+	count := 0
+	add := func(cards []Card, where string) {
+		for _, card := range cards {
+			if previous := seen[card]; len(previous) == g.Rules.DeckCount() {
+				c.fail(g, e, "card %s is in %s as well as in %s", card, where, strings.Join(previous, " and "))
+			}
+			seen[card] = append(seen[card], where)
+			count++
+		}
+	}
+	add(g.Deck, "the deck")
+	add(g.Box, "The Box")
+	for _, p := range g.Players {
+		add(p.Hand, fmt.Sprintf("player %d's hand", p.ID))
+		add(p.ScorePile, fmt.Sprintf("player %d's score pile", p.ID))
+	}
+	add(c.inPlay, "the trick")
+	if size := g.Rules.DeckSize(); count != size {
+		c.fail(g, e, "there are %d cards in the game instead of %d", count, size)
+	}
+}
+
+// checkBoard verifies that no attribute is placed twice and that the board
+// is full once drafting is over
+func (c *InvariantChecker) checkBoard(g *Game, e Event) {
+	if len(g.Board.Slots) != g.Edition().NumAttributes() {
+		c.fail(g, e, "the board has %d slots for %d attributes", len(g.Board.Slots), g.Edition().NumAttributes())
+	}
+	placed := make(map[Attribute]int)
+	empty := 0
+	for i, token := range g.Board.Slots {
+		if token == nil {
+			empty++
+			continue
+		}
+		if previous, ok := placed[token.Attribute]; ok {
+			c.fail(g, e, "%s is in slot %d and slot %d", g.Edition().AttributeName(token.Attribute), previous+1, i+1)
+		}
+		placed[token.Attribute] = i
+	}
+	drafting := e.Kind == EventHandStart || e.Kind == EventBid || e.Kind == EventDraft
+	if !drafting && empty > 0 {
+		c.fail(g, e, "%d slots are empty after the draft", empty)
+	}
+}
+
+// checkHands verifies that every player has the same number of cards
+func (c *InvariantChecker) checkHands(g *Game, e Event) {
+	if g.Rules.Auction == AuctionCards || g.Rules.TiesName() == TiesReplay {
+		return
+	}
+	for _, p := range g.Players {
+		if len(p.Hand) != len(g.Players[0].Hand) {
+			c.fail(g, e, "player %d holds %d cards but player 0 holds %d", p.ID, len(p.Hand), len(g.Players[0].Hand))
+		}
+	}
+}
+
+// fail panics with a description of the broken invariant and where it broke
+func (c *InvariantChecker) fail(g *Game, e Event, format string, args ...any) {
+	panic(fmt.Sprintf("invariant violated after %s (seed %d, hand %d, trick %d): %s",
+		e.Kind, c.Seed, g.HandNumber, g.TrickNumber, fmt.Sprintf(format, args...)))
+}
+
+// fuzzGame plays one game of random legal moves under rules and a player
+// count drawn from seed, with every invariant checked. It returns a
+// description of the game and the violation, if any.
+func fuzzGame(seed int64) (description string, violation string) {
+	rng := rand.New(rand.NewSource(seed))
+	numPlayers := 2 + rng.Intn(4)
+	rules := DefaultRules()
+	draft := rng.Intn(5)
+	if rng.Intn(3) == 0 {
+		// Other editions, down to one slot per player, or to a single slot
+		// for auctions, where nobody has to draft
+		fewest := numPlayers
+		if draft == 2 {
+			fewest = 1
+		}
+		rules.Attributes = fuzzAttributes(rng, fewest+rng.Intn(MaxAttributes-fewest+1))
+	}
+	if rng.Intn(2) == 0 {
+		// Short hands and low targets reach hand ends and sudden death often
+		rules.HandSize = 1 + rng.Intn(max(1, min(10, rules.DeckSize()/numPlayers)))
+		rules.TargetTricks = 1 + rng.Intn(12)
+	}
+	if rng.Intn(3) == 0 {
+		// A random selection of manipulations, in a random order
+		names := []string{}
+		for name := range actionKinds {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		rng.Shuffle(len(names), func(i, j int) { names[i], names[j] = names[j], names[i] })
+		rules.Actions = names[:1+rng.Intn(len(names))]
+	}
+	if rng.Intn(2) == 0 {
+		rules.NoRepeat = noRepeatRules[rng.Intn(len(noRepeatRules))]
+	}
+	if rng.Intn(2) == 0 {
+		rules.ActionOrder = actionOrderRules[rng.Intn(len(actionOrderRules))]
+	}
+	if rng.Intn(2) == 0 {
+		names := []string{}
+		for name := range scoringModes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		rules.Scoring = names[rng.Intn(len(names))]
+		if rules.Target(numPlayers) < 1 {
+			rules.TargetScore = 1
+		}
+	}
+	if rng.Intn(3) == 0 {
+		// The points judge, sometimes with random weights that tie often
+		rules.Judge = JudgePoints
+		rules.JudgeTieBreak = tieBreakNames[rng.Intn(len(tieBreakNames))]
+		if rng.Intn(2) == 0 {
+			rules.SlotWeights = make([]int, rules.Edition().NumAttributes())
+			for i := range rules.SlotWeights {
+				rules.SlotWeights[i] = rng.Intn(4)
+			}
+		}
+	}
+	if rng.Intn(3) == 0 {
+		// Extra decks, whose identical bees leave the Judge with ties
+		rules.Decks = 2 + rng.Intn(MaxDecks-1)
+	}
+	if rules.DeckSize() < numPlayers {
+		// Too few bees in one deck of a tiny auction edition to deal everyone one
+		rules.Decks = MaxDecks
+	}
+	if rules.JudgeName() != JudgePoints || rules.TieBreakName() == TieBreakSlots {
+		if rng.Intn(2) == 0 {
+			rules.Ties = tiesNames[rng.Intn(len(tiesNames))]
+		}
+	}
+	// The built-in schedules, random ones that still fill every slot, and
+	// auctions
+	switch draft {
+	case 0:
+		rules.Draft = []string{"snake", "reverse", "qf-first"}[rng.Intn(3)]
+	case 1:
+		rules.DraftSchedule = DraftSchedule{numPlayers: fuzzDraft(rng, numPlayers, rules.Edition().NumAttributes())}
+	case 2:
+		rules.Auction = []string{AuctionPoints, AuctionCards}[rng.Intn(2)]
+	}
+	// Small editions cannot deal the standard hand to everyone
+	rules.HandSize = min(rules.HandSize, rules.DeckSize()/numPlayers)
+
+	game := NewSeededGame(numPlayers, seed, false)
+	game.Rules = rules
+	specs := make([]string, numPlayers)
+	for i := range specs {
+		// Mostly random moves, with the odd real agent for realistic states
+		switch rng.Intn(7) {
+		case 0:
+			specs[i] = "greedy"
+			game.Agents = append(game.Agents, GreedyAgent{})
+		case 1:
+			specs[i] = "leader"
+			game.Agents = append(game.Agents, NewLeaderAgent())
+		case 2:
+			specs[i] = "heuristic"
+			game.Agents = append(game.Agents, &HeuristicAgent{Params: DefaultHeuristicParams()})
+		default:
+			specs[i] = "random"
+			game.Agents = append(game.Agents, &NoisyAgent{Base: GreedyAgent{}, Epsilon: 1})
+		}
+	}
+	if !checkInvariants {
+		game.Observers = append(game.Observers, &InvariantChecker{Seed: seed})
+	}
+	description = fmt.Sprintf("seed %d: %d players, %d attributes, %d decks, ties %s, %s draft, no repeat %s, %s order, %s judge, %s scoring, hand size %d, target %d, agents %s",
+		seed, numPlayers, rules.Edition().NumAttributes(), rules.DeckCount(), rules.TiesName(), rules.DraftName(), rules.NoRepeatRule(), rules.ActionOrderRule(), rules.JudgeName(), rules.ScoringName(), rules.HandSize, rules.Target(numPlayers), strings.Join(specs, ","))
+
+	defer func() {
+		if r := recover(); r != nil {
+			violation = fmt.Sprint(r)
+		}
+	}()
+	game.Run()
+	return description, ""
+}
+
+// fuzzAttributes names an edition with n attributes, some of them with
+// more than two values
+func fuzzAttributes(rng *rand.Rand, n int) []AttributeDef {
+	attributes := make([]AttributeDef, n)
+	for i := range attributes {
+		values := []string{fmt.Sprintf("A%dx", i), fmt.Sprintf("A%dy", i)}
+		if rng.Intn(4) == 0 {
+			values = append(values, fmt.Sprintf("A%dz", i))
+		}
+		attributes[i] = AttributeDef{Name: fmt.Sprintf("A%d", i), Values: values}
+	}
+	return attributes
+}
+
+// fuzzDraft deals the slots in a random order to random drafters, a few at
+// a time
+func fuzzDraft(rng *rand.Rand, numPlayers, numSlots int) []DraftTurn {
+	turns := []DraftTurn{}
+	slots := rng.Perm(numSlots)
+	for len(slots) > 0 {
+		n := 1 + rng.Intn(len(slots))
+		turns = append(turns, DraftTurn{Drafter: rng.Intn(numPlayers), Slots: slots[:n]})
+		slots = slots[n:]
+	}
+	return turns
+}
+
+// RunFuzzer plays numGames random games starting from seed firstSeed and
+// reports every game that breaks an invariant
+func RunFuzzer(numGames int, firstSeed int64) bool {
+	fmt.Printf("Fuzzing %d games from seed %d...\n", numGames, firstSeed)
+	failures := 0
+	for i := 0; i < numGames; i++ {
+		seed := firstSeed + int64(i)
+		description, violation := fuzzGame(seed)
+		if violation != "" {
+			failures++
+			fmt.Printf("FAIL %s\n  %s\n  reproduce with: go run . fuzz 1 %d\n", description, violation, seed)
+		}
+	}
+	if failures == 0 {
+		fmt.Printf("All %d games kept every invariant\n", numGames)
+		return true
+	}
+	fmt.Printf("%d of %d games broke an invariant\n", failures, numGames)
+	return false
+}
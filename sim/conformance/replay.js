@@ -0,0 +1,201 @@
+// Replays golden traces (see sim/conformance.go) through the web app's
+// BeeGame and reports the first step where it disagrees with the Go engine.
+//
+//   node conformance/replay.js ../web/eye-of-the-beeholder.html conformance/golden/*.json
+//
+// The engine is taken straight from the page: everything from the CONSTANTS
+// section up to the AI section, which holds the card data and BeeGame.
+'use strict';
+
+const fs = require('fs');
+const path = require('path');
+const vm = require('vm');
+
+function loadEngine(pagePath) {
+  const page = fs.readFileSync(pagePath, 'utf8');
+  const start = page.indexOf('// CONSTANTS\n');
+  const end = page.indexOf('\n// AI\n');
+  if (start < 0 || end < 0) throw new Error(`cannot find BeeGame in ${pagePath}`);
+  const source = page.slice(start, end) + '\n;({ BeeGame, CARD_DATA_RAW, actionsEqual })';
+  const context = { Math, console, fetch: () => Promise.reject(new Error('offline')) };
+  return vm.runInNewContext(source, context, { filename: pagePath });
+}
+
+// KNOWN_DIVERGENCES are disagreements on points the rules leave open, so
+// neither engine is wrong yet. A replay that meets one reports it, follows
+// the trace and carries on.
+const KNOWN_DIVERGENCES = {
+  queensFavorTie: "the rules do not say who takes the Queen's Favor when players tie for the most points",
+};
+
+// Divergence ends a replay at the first disagreement
+class Divergence extends Error {
+  constructor(step, expected, got) {
+    super(`step ${step}: expected ${expected}; engine ${got}`);
+  }
+}
+
+function describeAction(a) {
+  if (!a) return 'no action';
+  return a.type === 'swap' ? `swap slots ${a.slot + 1}+${(a.slot2 || 0) + 1}` : `flip slot ${a.slot + 1}`;
+}
+
+// Matches TraceStep.String in conformance.go
+function describe(step) {
+  if (!step) return 'the trace to continue';
+  switch (step.kind) {
+    case 'deal': return `deal with player ${step.player} holding the Queen's Favor`;
+    case 'draft': return `player ${step.player} drafts ${step.token} into slot ${(step.slot || 0) + 1}`;
+    case 'present': return `present ${step.cards.join(' ')}`;
+    case 'trick': return `player ${step.player} wins the trick, scores [${step.scores.join(' ')}], board ${step.board.join(' ')}`;
+    case 'manipulate': return `player ${step.player} manipulates: ${describeAction(step.action)}`;
+    case 'hand_end': return `hand ends, scores [${step.scores.join(' ')}]`;
+    case 'game_over': return `player ${step.player} wins the game, scores [${step.scores.join(' ')}]`;
+  }
+  return step.kind;
+}
+
+function replay(engine, trace) {
+  const { BeeGame, CARD_DATA_RAW } = engine;
+  const cardIds = {};
+  for (const [id, , attrs] of CARD_DATA_RAW) cardIds[attrs.join('')] = id;
+  const codeOf = id => CARD_DATA_RAW.find(c => c[0] === id)[2].join('');
+
+  const steps = trace.steps;
+  const known = new Set();
+  let next = 0;
+  const diverge = got => { throw new Divergence(next, describe(steps[next]), got); };
+  const expect = (kind, player, got) => {
+    const step = steps[next];
+    if (!step || step.kind !== kind || (player >= 0 && step.player !== player)) diverge(got);
+    next++;
+    return step;
+  };
+  const check = (kind, player, scores, board, got) => {
+    const step = expect(kind, player, got);
+    if (step.scores.join() !== scores.join() || (board && step.board.join() !== board.join())) {
+      next--;
+      diverge(got);
+    }
+  };
+
+  const players = [];
+  for (let i = 0; i < trace.players; i++) players.push({ name: `Player ${i}`, isHuman: true });
+  const game = new BeeGame(players, { cardsPerHand: trace.rules.hand_size, winScore: trace.rules.target_tricks });
+  const scores = () => game.players.map(p => p.score);
+  const board = () => game.facets.map(f => (f ? `${f.attrIndex}:${f.desiredValue}` : '-'));
+
+  // BeeGame shuffles its own deal; the trace's hands replace it
+  const deal = () => {
+    const traced = steps[next];
+    if (next > 0 && traced && traced.kind === 'deal' && traced.player !== game.qfPlayer) {
+      const top = Math.max(...scores());
+      if (game.players[traced.player].score === top && game.players[game.qfPlayer].score === top) {
+        known.add('queensFavorTie');
+        game.qfPlayer = traced.player;
+        game.draftSequence = game.getDraftSequence();
+      }
+    }
+    const step = expect('deal', game.qfPlayer, `deals hand ${game.hand} with player ${game.qfPlayer} holding the Queen's Favor`);
+    step.hands.forEach((hand, i) => {
+      game.players[i].hand = hand.map(code => {
+        if (!(code in cardIds)) throw new Error(`no card ${code} in CARD_DATA_RAW`);
+        return cardIds[code];
+      });
+    });
+  };
+  game.qfPlayer = steps[0].player;
+  game.startHand();
+  deal();
+
+  while (game.phase !== 'gameOver') {
+    switch (game.phase) {
+      case 'draft': {
+        const draft = game.getCurrentDraft();
+        const got = `asks player ${draft.player} to draft slot ${draft.slot}`;
+        const step = expect('draft', draft.player, got);
+        if ((step.slot || 0) !== draft.slot - 1) { next--; diverge(got); }
+        const [attr, value] = step.token.split(':').map(Number);
+        if (!game.availableTiles.includes(attr)) {
+          next--;
+          diverge(`cannot place ${step.token}; available attributes are [${game.availableTiles.join(' ')}]`);
+        }
+        game.draftTile(attr, value);
+        break;
+      }
+      case 'present': {
+        const step = expect('present', -1, `asks for cards in round ${game.roundInHand}`);
+        step.cards.forEach((code, i) => {
+          const id = cardIds[code];
+          if (!game.players[i].hand.includes(id)) {
+            next--;
+            diverge(`has no card ${code} in player ${i}'s hand ${game.players[i].hand.map(codeOf).join(' ')}`);
+          }
+          game.presentCard(i, id);
+        });
+        const judged = board();
+        const result = game.judge();
+        check('trick', result.winner, scores(), judged,
+          `gives the trick to player ${result.winner}, scores [${scores().join(' ')}], board ${judged.join(' ')}`);
+        if (game.phase === 'gameOver') break;
+        // As the page's skipToHandEnd and proceedToManipulate do
+        if (result.skipManip) game.phase = 'handEnd';
+        else game.startManipulate();
+        break;
+      }
+      case 'manipulate': {
+        const player = game.getCurrentManipulator();
+        const step = expect('manipulate', player, `asks player ${player} to manipulate`);
+        const a = step.action;
+        const action = a.type === 'swap'
+          ? { type: 'swap', slotA: a.slot || 0, slotB: a.slot2 || 0 }
+          : { type: 'flip', slot: a.slot || 0 };
+        if (!game.isValidManipulation(action)) {
+          next--;
+          diverge(`does not allow ${describeAction(a)}`);
+        }
+        game.applyManipulation(action);
+        break;
+      }
+      case 'handEnd': {
+        check('hand_end', -1, scores(), null, `ends the hand, scores [${scores().join(' ')}]`);
+        const wasSuddenDeath = game.suddenDeath;
+        if (game.resolveHandEnd() === 'gameOver') break;
+        if (game.suddenDeath && !wasSuddenDeath) expect('sudden_death', -1, 'starts sudden death');
+        deal();
+        break;
+      }
+      default:
+        throw new Error(`unexpected phase ${game.phase}`);
+    }
+  }
+
+  check('game_over', game.winner, scores(), null,
+    `ends the game won by player ${game.winner}, scores [${scores().join(' ')}]`);
+  if (next < steps.length) diverge('ends the game');
+  return [...known];
+}
+
+function main() {
+  const [pagePath, ...tracePaths] = process.argv.slice(2);
+  if (!pagePath || tracePaths.length === 0) {
+    console.log('Usage: node replay.js <page.html> <trace.json>...');
+    process.exit(2);
+  }
+  const engine = loadEngine(pagePath);
+  let failed = false;
+  for (const tracePath of tracePaths) {
+    const name = path.basename(tracePath);
+    try {
+      const known = replay(engine, JSON.parse(fs.readFileSync(tracePath, 'utf8')));
+      const notes = known.map(k => `\n    known divergence: ${KNOWN_DIVERGENCES[k]}`).join('');
+      console.log(`  ${name}: ok${notes}`);
+    } catch (err) {
+      failed = true;
+      console.log(`  ${name}: ${err instanceof Divergence ? 'DIVERGED at ' : ''}${err.message}`);
+    }
+  }
+  process.exit(failed ? 1 : 0);
+}
+
+main();
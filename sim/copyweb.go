@@ -0,0 +1,60 @@
+//go:build ignore
+
+// copyweb replaces ./web with a copy of ../web, for assets_embed.go to
+// embed. It is run by go generate (see assets.go).
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	if err := os.RemoveAll("web"); err != nil {
+		fail(err)
+	}
+	src := filepath.Join("..", "web")
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join("web", rel)
+		if d.IsDir() {
+			return os.MkdirAll(dst, 0o755)
+		}
+		return copyFile(path, dst)
+	})
+	if err != nil {
+		fail(err)
+	}
+}
+
+// copyFile copies one file's contents
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "copyweb:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DraftTurn is one player's turn in the draft: the drafter, counted
+// counter-clockwise from the player after the QF holder, fills the slots in
+// order
+type DraftTurn struct {
+	Drafter int   `json:"drafter"` // 0 drafts first in the standard game; the QF holder is NumPlayers-1
+	Slots   []int `json:"slots"`   // 0 for Slot 1
+}
+
+// DraftSchedule lists the turns of the draft for each player count. Player
+// counts it leaves out use the rules' built-in schedule.
+type DraftSchedule map[int][]DraftTurn
+
+// draftSchedules are the built-in schedules, by the name rules files use.
+// Each fills every slot for any player and slot count.
+var draftSchedules = map[string]func(numPlayers, numSlots int) []DraftTurn{
+	"standard": standardDraft,
+	"snake":    snakeDraft,
+	"reverse":  reverseDraft,
+	"qf-first": qfFirstDraft,
+}
+
+// draftScheduleNames lists the built-in schedules for error messages
+func draftScheduleNames() string {
+	names := make([]string, 0, len(draftSchedules))
+	for name := range draftSchedules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// standardDraft fills the slots from the last up to Slot 1, which the QF
+// holder always drafts. With two players the drafters alternate slot by slot
+// (first drafter 6,4,2; QF holder 5,3,1 with six slots). Otherwise each
+// player drafts a block of slots, the earliest drafters taking one extra
+// when the slots do not divide evenly: with six slots, 3 players draft
+// 6,5 / 4,3 / 2,1, 4 players 6,5 / 4,3 / 2 / 1 and 5 players 6,5 / 4 / 3 /
+// 2 / 1.
+func standardDraft(numPlayers, numSlots int) []DraftTurn {
+	turns := []DraftTurn{}
+	slot := numSlots - 1
+	if numPlayers == 2 {
+		for drafter := numSlots % 2; slot >= 0; drafter = 1 - drafter {
+			turns = append(turns, DraftTurn{Drafter: drafter, Slots: []int{slot}})
+			slot--
+		}
+		return turns
+	}
+	for drafter := 0; drafter < numPlayers; drafter++ {
+		count := numSlots / numPlayers
+		if drafter < numSlots%numPlayers {
+			count++
+		}
+		turn := DraftTurn{Drafter: drafter}
+		for ; count > 0; count-- {
+			turn.Slots = append(turn.Slots, slot)
+			slot--
+		}
+		turns = append(turns, turn)
+	}
+	return turns
+}
+
+// snakeDraft fills one slot per turn from the last up to Slot 1, going
+// counter-clockwise to the QF holder and then back: with six slots and four
+// players the first three drafters take 6 / 5 / 4, the QF holder 3 and 2,
+// and the third drafter Slot 1
+func snakeDraft(numPlayers, numSlots int) []DraftTurn {
+	turns := []DraftTurn{}
+	drafter, step := 0, 1
+	for slot := numSlots - 1; slot >= 0; slot-- {
+		turns = append(turns, DraftTurn{Drafter: drafter, Slots: []int{slot}})
+		if next := drafter + step; next < 0 || next >= numPlayers {
+			step = -step // The end of the table drafts twice in a row
+		} else {
+			drafter = next
+		}
+	}
+	return turns
+}
+
+// reverseDraft is the standard schedule with its drafters mirrored, so the
+// turns go clockwise starting from the QF holder, who drafts first. Slot 1
+// goes last, to the player counter-clockwise of the QF holder. With six
+// slots and two players the QF holder drafts 6,4,2 and the other player
+// 5,3,1; with four, clockwise from the QF holder, 6,5 / 4,3 / 2 / 1.
+func reverseDraft(numPlayers, numSlots int) []DraftTurn {
+	return renumberDrafters(standardDraft(numPlayers, numSlots), func(drafter int) int {
+		return numPlayers - 1 - drafter
+	})
+}
+
+// qfFirstDraft is the standard schedule with the QF holder's turn moved to
+// the front: they take the last slots and the others follow
+// counter-clockwise, the player before the QF holder drafting Slot 1
+func qfFirstDraft(numPlayers, numSlots int) []DraftTurn {
+	return renumberDrafters(standardDraft(numPlayers, numSlots), func(drafter int) int {
+		return (drafter + numPlayers - 1) % numPlayers
+	})
+}
+
+// renumberDrafters gives each turn to another drafter
+func renumberDrafters(turns []DraftTurn, drafterFor func(int) int) []DraftTurn {
+	for i := range turns {
+		turns[i].Drafter = drafterFor(turns[i].Drafter)
+	}
+	return turns
+}
+
+// validateDraft checks that turns fill every slot exactly once and that
+// every drafter is at the table
+func validateDraft(turns []DraftTurn, numPlayers, numSlots int) error {
+	filled := make([]bool, numSlots)
+	for _, turn := range turns {
+		if turn.Drafter < 0 || turn.Drafter >= numPlayers {
+			return fmt.Errorf("drafter %d is not at a %d-player table", turn.Drafter, numPlayers)
+		}
+		if len(turn.Slots) == 0 {
+			return fmt.Errorf("drafter %d has a turn with no slots", turn.Drafter)
+		}
+		for _, slot := range turn.Slots {
+			if slot < 0 || slot >= numSlots {
+				return fmt.Errorf("slot index %d is outside the board's %d slots", slot, numSlots)
+			}
+			if filled[slot] {
+				return fmt.Errorf("slot %d is drafted twice", slot+1)
+			}
+			filled[slot] = true
+		}
+	}
+	for slot, ok := range filled {
+		if !ok {
+			return fmt.Errorf("slot %d is never drafted", slot+1)
+		}
+	}
+	return nil
+}
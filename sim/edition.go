@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	MaxAttributes = 8 // Most attributes an edition can have; a card holds a value for each
+	MaxValues     = 4 // Most sides an attribute's tile can have
+)
+
+// AttributeDef names an attribute and its values. Tiles for attributes with
+// more than two values rotate rather than flip.
+type AttributeDef struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"` // Value 0 first
+}
+
+// Edition is the set of attributes a deck is printed with. Every combination
+// of their values is one card, and the Queen's Favor has a slot for each.
+type Edition struct {
+	Attributes []AttributeDef
+}
+
+// printedEdition is the published game: six attributes and 64 bees
+var printedEdition = EditionFor([]AttributeDef{
+	{"Texture", []string{"Fuzzy", "Shiny"}},
+	{"Antennae", []string{"Feathered", "Whips"}},
+	{"Weapon", []string{"Stinger", "Mandibles"}},
+	{"Pattern", []string{"Striped", "Solid"}},
+	{"Wings", []string{"Sleek", "Flutter"}},
+	{"Payload", []string{"Honey", "Pollen"}},
+})
+
+// maxEditions is how many editions EditionFor remembers
+const maxEditions = 256
+
+// editions maps the JSON of recently used attribute lists to their Edition.
+// Servers build editions from the rules clients send, so the cache drops an
+// entry when full rather than keep every edition it was ever sent.
+var editions = struct {
+	sync.Mutex
+	byKey map[string]*Edition
+}{byKey: make(map[string]*Edition)}
+
+// EditionFor returns the Edition with the given attributes. Equal
+// definitions give the same Edition while it is cached, so cards from games
+// played under the same rules compare equal. A game keeps the Edition it
+// started with (see Game.Edition), so a dropped entry cannot split its cards.
+func EditionFor(attributes []AttributeDef) *Edition {
+	data, err := json.Marshal(attributes)
+	if err != nil {
+		panic(err)
+	}
+	key := string(data)
+
+	editions.Lock()
+	defer editions.Unlock()
+	if e := editions.byKey[key]; e != nil {
+		return e
+	}
+	if len(editions.byKey) >= maxEditions {
+		for old := range editions.byKey {
+			delete(editions.byKey, old)
+			break
+		}
+	}
+	e := &Edition{Attributes: attributes}
+	editions.byKey[key] = e
+	return e
+}
+
+// Describes reports whether the edition has the given attributes, nil
+// standing for the printed ones
+func (e *Edition) Describes(attributes []AttributeDef) bool {
+	if attributes == nil {
+		return e == printedEdition
+	}
+	return slices.EqualFunc(e.Attributes, attributes, func(a, b AttributeDef) bool {
+		return a.Name == b.Name && slices.Equal(a.Values, b.Values)
+	})
+}
+
+// Validate checks that every attribute has two to MaxValues values, and
+// that every attribute and value has a distinct name
+func (e *Edition) Validate() error {
+	if len(e.Attributes) < 1 || len(e.Attributes) > MaxAttributes {
+		return fmt.Errorf("an edition has between 1 and %d attributes, not %d", MaxAttributes, len(e.Attributes))
+	}
+	names := make(map[string]bool)
+	for _, attr := range e.Attributes {
+		if attr.Name == "" || names[strings.ToLower(attr.Name)] {
+			return fmt.Errorf("attribute names must be distinct and not empty, got %q", attr.Name)
+		}
+		names[strings.ToLower(attr.Name)] = true
+		if len(attr.Values) < 2 || len(attr.Values) > MaxValues {
+			return fmt.Errorf("%s has %d values; attributes have between 2 and %d", attr.Name, len(attr.Values), MaxValues)
+		}
+		values := make(map[string]bool)
+		for _, value := range attr.Values {
+			if value == "" || values[strings.ToLower(value)] {
+				return fmt.Errorf("%s needs distinct value names, not empty ones", attr.Name)
+			}
+			values[strings.ToLower(value)] = true
+		}
+	}
+	return nil
+}
+
+// NumAttributes is the number of attributes, and of slots on the board
+func (e *Edition) NumAttributes() int {
+	return len(e.Attributes)
+}
+
+// NumValues is the number of values the attribute has, usually 2
+func (e *Edition) NumValues(attr Attribute) int {
+	return len(e.Attributes[attr].Values)
+}
+
+// DeckSize is the number of distinct bees: the product of every
+// attribute's number of values
+func (e *Edition) DeckSize() int {
+	size := 1
+	for _, attr := range e.Attributes {
+		size *= len(attr.Values)
+	}
+	return size
+}
+
+// MaxScore is the greedy score of a card matching every slot
+func (e *Edition) MaxScore() int {
+	return 1<<len(e.Attributes) - 1
+}
+
+// AllAttributes lists the attributes in order
+func (e *Edition) AllAttributes() []Attribute {
+	attrs := make([]Attribute, len(e.Attributes))
+	for i := range attrs {
+		attrs[i] = Attribute(i)
+	}
+	return attrs
+}
+
+// AttributeName returns the attribute's name, such as "Texture"
+func (e *Edition) AttributeName(attr Attribute) string {
+	return e.Attributes[attr].Name
+}
+
+// ValueName returns the name of one of the attribute's values, such as "Shiny"
+func (e *Edition) ValueName(attr Attribute, value int) string {
+	return e.Attributes[attr].Values[value]
+}
+
+// ValueList joins the attribute's value names, such as "Fuzzy/Shiny"
+func (e *Edition) ValueList(attr Attribute, sep string) string {
+	return strings.Join(e.Attributes[attr].Values, sep)
+}
+
+// ParseValue accepts a value name such as "Shiny" for the attribute
+func (e *Edition) ParseValue(s string, attr Attribute) (int, bool) {
+	for i, name := range e.Attributes[attr].Values {
+		if strings.EqualFold(name, s) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// editionOf returns e, or the printed edition for cards and tokens built
+// without one
+func editionOf(e *Edition) *Edition {
+	if e == nil {
+		return printedEdition
+	}
+	return e
+}
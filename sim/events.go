@@ -0,0 +1,76 @@
+package main
+
+// EventKind identifies what happened at the table
+type EventKind string
+
+const (
+	EventHandStart   EventKind = "hand_start"
+	EventBid         EventKind = "bid"
+	EventDraft       EventKind = "draft"
+	EventReveal      EventKind = "reveal"
+	EventReplay      EventKind = "replay"
+	EventJudgeSlot   EventKind = "judge_slot"
+	EventJudgeTotals EventKind = "judge_totals"
+	EventTrickWon    EventKind = "trick_won"
+	EventAction      EventKind = "action"
+	EventHandEnd     EventKind = "hand_end"
+	EventSuddenDeath EventKind = "sudden_death"
+	EventGameOver    EventKind = "game_over"
+)
+
+// Event describes one public step of the game. Only the fields that make
+// sense for the kind are set.
+type Event struct {
+	Kind       EventKind       `json:"kind"`
+	PlayerID   int             `json:"player"`               // Drafter, manipulator, or auction, trick or game winner
+	SlotIndex  int             `json:"slot"`                 // Slot auctioned, drafted or judged
+	Token      *AttributeToken `json:"token,omitempty"`      // Token drafted or judged
+	Action     *Action         `json:"action,omitempty"`     // Manipulation taken
+	Plays      []Play          `json:"plays,omitempty"`      // Cards revealed this trick, or presented again to settle a tie
+	Eliminated []int           `json:"eliminated,omitempty"` // Players knocked out at the judged slot, or below the top total
+	Survivors  []int           `json:"survivors,omitempty"`  // Players still in after the judged slot, or sharing the top total
+	Bids       []int           `json:"bids,omitempty"`       // Sealed bids for the auctioned slot, by player
+	Cancelled  bool            `json:"cancelled,omitempty"`  // Secret manipulation that an earlier one ruled out
+	Points     int             `json:"points,omitempty"`     // What the trick won scores
+	Totals     []int           `json:"totals,omitempty"`     // Points each player's card scored under the points judge
+	Shares     []int           `json:"shares,omitempty"`     // Points each player took when tied players split the trick
+}
+
+// pointsFor is what a trick's points give the player: their share when the
+// trick was split, otherwise all of them to the winner
+func (e Event) pointsFor(playerID int) int {
+	return pointShare(e.Shares, e.Points, e.PlayerID, playerID)
+}
+
+// pointShare is what a trick worth points gives the player: their entry in
+// shares when the trick was split, otherwise all of it to the winner
+func pointShare(shares []int, points int, winnerID int, playerID int) int {
+	if shares != nil {
+		return shares[playerID]
+	}
+	if playerID == winnerID {
+		return points
+	}
+	return 0
+}
+
+// Observer is told about every event as the game plays out
+type Observer interface {
+	Observe(g *Game, e Event)
+}
+
+// emit passes an event to every observer
+func (g *Game) emit(e Event) {
+	for _, o := range g.Observers {
+		o.Observe(g, e)
+	}
+}
+
+// playerIDs maps indices into plays to the players who made them
+func playerIDs(plays []Play, indices []int) []int {
+	ids := make([]int, len(indices))
+	for i, idx := range indices {
+		ids[i] = plays[idx].PlayerID
+	}
+	return ids
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// Genetic algorithm settings
+const (
+	populationSize     = 16
+	eliteCount         = 2
+	selectionSize      = 3   // Candidates compared in each selection tournament
+	mutationRate       = 0.3 // Chance that each parameter mutates
+	gamesPerGenSeat    = 100 // Games per candidate per generation
+	maxCardWeight      = 5.0
+	maxManipWeight     = 5.0
+	maxSlot1Bonus      = 200.0
+	maxSlot2Bonus      = 50.0
+	maxMajoritySlots   = 6
+	mutationScale      = 0.15 // Mutation step as a fraction of each parameter's range
+	evaluationGames    = 1000 // Games per player count when measuring the winner
+	defaultGenerations = 20
+)
+
+// candidate is one member of the population with its latest fitness
+type candidate struct {
+	Params  HeuristicParams
+	Wins    int
+	Games   int
+	Fitness float64
+}
+
+// EvolveHeuristicParams tunes HeuristicParams with a genetic algorithm. Each
+// generation the whole population plays a round of seeded games at mixed
+// table sizes, and a candidate's fitness is its share of the games it won.
+func EvolveHeuristicParams(generations int, seed int64) HeuristicParams {
+	rng := rand.New(rand.NewSource(seed))
+
+	population := make([]*candidate, populationSize)
+	population[0] = &candidate{Params: DefaultHeuristicParams()}
+	for i := 1; i < populationSize; i++ {
+		population[i] = &candidate{Params: randomHeuristicParams(rng)}
+	}
+
+	fmt.Printf("Evolving heuristic parameters: %d generations of %d candidates...\n", generations, populationSize)
+
+	for gen := 0; gen < generations; gen++ {
+		playGeneration(population, rng)
+		sort.SliceStable(population, func(i, j int) bool {
+			return population[i].Fitness > population[j].Fitness
+		})
+
+		best := population[0]
+		fmt.Printf("  Generation %2d: best %.1f%% (%d/%d) %s\n",
+			gen+1, best.Fitness*100, best.Wins, best.Games, best.Params)
+
+		if gen == generations-1 {
+			break
+		}
+
+		// Keep the elites and breed the rest
+		next := make([]*candidate, 0, populationSize)
+		for i := 0; i < eliteCount; i++ {
+			next = append(next, &candidate{Params: population[i].Params})
+		}
+		for len(next) < populationSize {
+			mother := selectParent(population, rng)
+			father := selectParent(population, rng)
+			child := crossover(mother.Params, father.Params, rng)
+			next = append(next, &candidate{Params: mutate(child, rng)})
+		}
+		population = next
+	}
+
+	return population[0].Params
+}
+
+// playGeneration plays seeded games between randomly drawn candidates and
+// sets every candidate's fitness. Table sizes cycle through 2-5 players.
+func playGeneration(population []*candidate, rng *rand.Rand) {
+	for _, c := range population {
+		c.Wins, c.Games = 0, 0
+	}
+
+	numGames := gamesPerGenSeat * len(population) * 2 / 7 // Average table has 3.5 seats
+	for i := 0; i < numGames; i++ {
+		numPlayers := 2 + i%4
+		seated := rng.Perm(len(population))[:numPlayers]
+
+		agents := make([]Agent, numPlayers)
+		for seat, idx := range seated {
+			agents[seat] = &HeuristicAgent{Params: population[idx].Params}
+			population[idx].Games++
+		}
+
+		winner := playSeededTable(rng.Int63(), agents)
+		population[seated[winner]].Wins++
+	}
+
+	for _, c := range population {
+		c.Fitness = 0
+		if c.Games > 0 {
+			c.Fitness = float64(c.Wins) / float64(c.Games)
+		}
+	}
+}
+
+// selectParent returns the fittest of a few randomly chosen candidates
+func selectParent(population []*candidate, rng *rand.Rand) *candidate {
+	best := population[rng.Intn(len(population))]
+	for i := 1; i < selectionSize; i++ {
+		c := population[rng.Intn(len(population))]
+		if c.Fitness > best.Fitness {
+			best = c
+		}
+	}
+	return best
+}
+
+// crossover takes each parameter from one parent or the other
+func crossover(a, b HeuristicParams, rng *rand.Rand) HeuristicParams {
+	child := a
+	if rng.Intn(2) == 0 {
+		child.MajoritySlots = b.MajoritySlots
+	}
+	if rng.Intn(2) == 0 {
+		child.CardWeight = b.CardWeight
+	}
+	if rng.Intn(2) == 0 {
+		child.ManipWeight = b.ManipWeight
+	}
+	if rng.Intn(2) == 0 {
+		child.Slot1Bonus = b.Slot1Bonus
+	}
+	if rng.Intn(2) == 0 {
+		child.Slot2Bonus = b.Slot2Bonus
+	}
+	return child
+}
+
+// mutate nudges each parameter with probability mutationRate
+func mutate(p HeuristicParams, rng *rand.Rand) HeuristicParams {
+	if rng.Float64() < mutationRate {
+		p.MajoritySlots += rng.Intn(3) - 1
+		if p.MajoritySlots < 0 {
+			p.MajoritySlots = 0
+		}
+		if p.MajoritySlots > maxMajoritySlots {
+			p.MajoritySlots = maxMajoritySlots
+		}
+	}
+	p.CardWeight = mutateFloat(p.CardWeight, maxCardWeight, rng)
+	p.ManipWeight = mutateFloat(p.ManipWeight, maxManipWeight, rng)
+	p.Slot1Bonus = mutateFloat(p.Slot1Bonus, maxSlot1Bonus, rng)
+	p.Slot2Bonus = mutateFloat(p.Slot2Bonus, maxSlot2Bonus, rng)
+	return p
+}
+
+// mutateFloat applies a Gaussian step to a parameter in [0, max]
+func mutateFloat(value float64, max float64, rng *rand.Rand) float64 {
+	if rng.Float64() >= mutationRate {
+		return value
+	}
+	value += rng.NormFloat64() * max * mutationScale
+	if value < 0 {
+		return 0
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
+
+// randomHeuristicParams draws parameters uniformly from their ranges
+func randomHeuristicParams(rng *rand.Rand) HeuristicParams {
+	return HeuristicParams{
+		MajoritySlots: rng.Intn(maxMajoritySlots + 1),
+		CardWeight:    rng.Float64() * maxCardWeight,
+		ManipWeight:   rng.Float64() * maxManipWeight,
+		Slot1Bonus:    rng.Float64() * maxSlot1Bonus,
+		Slot2Bonus:    rng.Float64() * maxSlot2Bonus,
+	}
+}
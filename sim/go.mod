@@ -0,0 +1,3 @@
+module github.com/schwardo/eye-of-the-beeholder/sim
+
+go 1.22
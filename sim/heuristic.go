@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+const defaultParamsFile = "params.json"
+
+// HeuristicParams are the tunable numbers behind the hand-written AIs
+type HeuristicParams struct {
+	MajoritySlots int     `json:"majority_slots"` // Leading slots drafted with the majority value (selectBestToken uses 1)
+	CardWeight    float64 `json:"card_weight"`    // Weight on a card's own score (web strategic uses 2)
+	ManipWeight   float64 `json:"manip_weight"`   // Weight on the best remaining card after a manipulation (web strategic uses 1)
+	Slot1Bonus    float64 `json:"slot1_bonus"`    // Bonus for matching Slot 1 (web defensive uses 100)
+	Slot2Bonus    float64 `json:"slot2_bonus"`    // Bonus for matching Slot 2 (web defensive uses 20)
+}
+
+// DefaultHeuristicParams returns the magic numbers the AIs started with
+func DefaultHeuristicParams() HeuristicParams {
+	return HeuristicParams{
+		MajoritySlots: 1,
+		CardWeight:    2,
+		ManipWeight:   1,
+		Slot1Bonus:    100,
+		Slot2Bonus:    20,
+	}
+}
+
+// LoadHeuristicParams reads parameters saved by SaveHeuristicParams
+func LoadHeuristicParams(path string) (HeuristicParams, error) {
+	params := DefaultHeuristicParams()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return params, err
+	}
+	if err := json.Unmarshal(data, &params); err != nil {
+		return params, fmt.Errorf("%s: %v", path, err)
+	}
+	return params, nil
+}
+
+// SaveHeuristicParams writes the parameters as JSON
+func SaveHeuristicParams(path string, params HeuristicParams) error {
+	data, err := json.MarshalIndent(params, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// String formats the parameters on one line for reports
+func (p HeuristicParams) String() string {
+	return fmt.Sprintf("majority_slots=%d card_weight=%.2f manip_weight=%.2f slot1_bonus=%.1f slot2_bonus=%.1f",
+		p.MajoritySlots, p.CardWeight, p.ManipWeight, p.Slot1Bonus, p.Slot2Bonus)
+}
+
+// HeuristicAgent combines the greedy draft, the web app's strategic
+// look-ahead and the defensive slot bonuses, weighted by its parameters
+type HeuristicAgent struct {
+	Params HeuristicParams
+}
+
+func (a *HeuristicAgent) ChooseToken(g *Game, player *Player, availableTokens []Attribute, slotIdx int) (Attribute, int) {
+	return g.selectTokenFor(player, availableTokens, slotIdx, a.Params.MajoritySlots)
+}
+
+func (a *HeuristicAgent) ChooseCard(g *Game, player *Player) int {
+	return bestIndex(a.ScoreCards(g, player))
+}
+
+func (a *HeuristicAgent) ChooseAction(g *Game, player *Player, previousAction *Action) Action {
+	return g.selectBestAction(player, previousAction)
+}
+
+func (a *HeuristicAgent) ScoreTokens(g *Game, player *Player, availableTokens []Attribute, slotIdx int) []float64 {
+	return g.tokenScores(player, availableTokens, slotIdx, a.Params.MajoritySlots)
+}
+
+// ScoreCards weighs each card's own score, the best remaining card after a
+// manipulation, and the Slot 1 and Slot 2 bonuses
+func (a *HeuristicAgent) ScoreCards(g *Game, player *Player) []float64 {
+	values := make([]float64, len(player.Hand))
+	for i, card := range player.Hand {
+		value := a.Params.CardWeight * g.strength(g.scoreCard(card))
+
+		// Look ahead: how good could our remaining hand be after we manipulate?
+		if a.Params.ManipWeight != 0 && len(player.Hand) > 1 {
+			remaining := &Player{ID: player.ID}
+			remaining.Hand = append(remaining.Hand, player.Hand[:i]...)
+			remaining.Hand = append(remaining.Hand, player.Hand[i+1:]...)
+			bestManipValue := 0
+			for _, action := range g.legalActions(player, nil) {
+				score, _ := g.scoreActionOutcome(remaining, action)
+				if score > bestManipValue {
+					bestManipValue = score
+				}
+			}
+			value += a.Params.ManipWeight * g.strength(bestManipValue)
+		}
+
+		if token := g.Board.Slots[0]; token != nil && card.Matches(token.Attribute, token.Value) {
+			value += a.Params.Slot1Bonus
+		}
+		if len(g.Board.Slots) > 1 { // Auction editions can have a single slot
+			if token := g.Board.Slots[1]; token != nil && card.Matches(token.Attribute, token.Value) {
+				value += a.Params.Slot2Bonus
+			}
+		}
+
+		values[i] = value
+	}
+	return values
+}
+
+func (a *HeuristicAgent) ScoreActions(g *Game, player *Player, actions []Action) []float64 {
+	return g.actionScores(player, actions)
+}
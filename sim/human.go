@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// HumanAgent asks a person at the terminal for every decision
+type HumanAgent struct {
+	in  *bufio.Scanner // Shared by every human at the same terminal
+	out io.Writer
+}
+
+// NewHumanAgent creates a human seat reading from in and writing to out
+func NewHumanAgent(in *bufio.Scanner, out io.Writer) *HumanAgent {
+	return &HumanAgent{in: in, out: out}
+}
+
+func (h *HumanAgent) ChooseToken(g *Game, player *Player, availableTokens []Attribute, slotIdx int) (Attribute, int) {
+	h.showTable(g, player)
+	edition := g.Edition()
+	fmt.Fprintf(h.out, "\nPlayer %d, choose a tile for Slot %d:\n", player.ID, slotIdx+1)
+	for i, attr := range availableTokens {
+		fmt.Fprintf(h.out, "  %d) %-9s %s\n", i+1, edition.AttributeName(attr), edition.ValueList(attr, " / "))
+	}
+
+	for {
+		fields := strings.Fields(h.prompt("Tile and side (e.g. \"1 " + edition.ValueName(availableTokens[0], 1) + "\")"))
+		if len(fields) != 2 {
+			fmt.Fprintln(h.out, "Enter a tile number or name followed by the side to show.")
+			continue
+		}
+		attr, ok := parseAttribute(fields[0], edition, availableTokens)
+		if !ok {
+			fmt.Fprintf(h.out, "%q is not one of the available tiles.\n", fields[0])
+			continue
+		}
+		value, ok := edition.ParseValue(fields[1], attr)
+		if !ok {
+			fmt.Fprintf(h.out, "%s is one of %s.\n", edition.AttributeName(attr), edition.ValueList(attr, ", "))
+			continue
+		}
+		return attr, value
+	}
+}
+
+func (h *HumanAgent) ChooseCard(g *Game, player *Player) int {
+	h.showTable(g, player)
+	for {
+		n, err := strconv.Atoi(h.prompt(fmt.Sprintf("\nPlayer %d, present which bee? (1-%d)", player.ID, len(player.Hand))))
+		if err != nil || n < 1 || n > len(player.Hand) {
+			fmt.Fprintf(h.out, "Enter a number from 1 to %d.\n", len(player.Hand))
+			continue
+		}
+		return n - 1
+	}
+}
+
+func (h *HumanAgent) ChooseAction(g *Game, player *Player, previousAction *Action) Action {
+	h.showTable(g, player)
+	if reminder := repeatReminder(g, previousAction); reminder != "" {
+		fmt.Fprintf(h.out, "\n%s\n", reminder)
+	}
+
+	legal := g.legalActions(player, previousAction)
+	for {
+		input := h.prompt(fmt.Sprintf("\nPlayer %d, manipulate the Queen's Favor (%s)", player.ID, actionUsage(g.Rules.ActionNames())))
+		action, err := parseAction(input, g)
+		if err != nil {
+			fmt.Fprintln(h.out, err)
+			continue
+		}
+		for _, l := range legal {
+			if actionsMatch(l, action) {
+				return l
+			}
+		}
+		fmt.Fprintln(h.out, illegalActionMessage(g, action, previousAction))
+	}
+}
+
+// ChooseBid asks for a sealed bid; with nothing to pay, the bid is nothing
+func (h *HumanAgent) ChooseBid(g *Game, player *Player, availableTokens []Attribute, slotIdx int, maxBid int) int {
+	if maxBid == 0 {
+		return 0
+	}
+	h.showTable(g, player)
+	for {
+		question := fmt.Sprintf("\nPlayer %d, bid for Slot %d (0 to %s)", player.ID, slotIdx+1, g.auctionUnit(maxBid))
+		n, err := strconv.Atoi(h.prompt(question))
+		if err != nil || n < 0 || n > maxBid {
+			fmt.Fprintf(h.out, "Enter a number from 0 to %d.\n", maxBid)
+			continue
+		}
+		return n
+	}
+}
+
+func (h *HumanAgent) ChooseDiscards(g *Game, player *Player, n int) []int {
+	h.showTable(g, player)
+	for {
+		discards, err := parseDiscards(h.prompt(fmt.Sprintf("\nPlayer %d, discard which %d bees to pay?", player.ID, n)), n, len(player.Hand))
+		if err != nil {
+			fmt.Fprintln(h.out, err)
+			continue
+		}
+		return discards
+	}
+}
+
+// prompt reads one line, leaving the game if input runs out
+func (h *HumanAgent) prompt(question string) string {
+	fmt.Fprintf(h.out, "%s: ", question)
+	if !h.in.Scan() {
+		fmt.Fprintln(h.out, "\nGoodbye!")
+		os.Exit(0)
+	}
+	return strings.TrimSpace(h.in.Text())
+}
+
+// showTable prints the scores, the Queen's Favor and the player's hand with
+// the slots each bee matches
+func (h *HumanAgent) showTable(g *Game, player *Player) {
+	fmt.Fprintln(h.out, "\nScores:")
+	for _, p := range g.Players {
+		marker := ""
+		if p.ID == player.ID {
+			marker = " (you)"
+		}
+		fmt.Fprintf(h.out, "  Player %d: %s%s\n", p.ID, g.scoreLabel(p.Score), marker)
+	}
+
+	fmt.Fprintln(h.out, "\nQueen's Favor:")
+	for i, token := range g.Board.Slots {
+		if token != nil {
+			fmt.Fprintf(h.out, "  Slot %d: %s\n", i+1, token)
+		} else {
+			fmt.Fprintf(h.out, "  Slot %d: (empty)\n", i+1)
+		}
+	}
+
+	fmt.Fprintf(h.out, "\nPlayer %d's hand:\n", player.ID)
+	for i, card := range player.Hand {
+		matches := []string{}
+		for slot, token := range g.Board.Slots {
+			if token != nil && card.Matches(token.Attribute, token.Value) {
+				matches = append(matches, strconv.Itoa(slot+1))
+			}
+		}
+		fmt.Fprintf(h.out, "  %d) %s  matches slots: %s\n", i+1, card, strings.Join(matches, " "))
+	}
+
+	for _, peek := range player.knownHeld() {
+		fmt.Fprintf(h.out, "  You have seen that Player %d holds %s\n", peek.Holder, peek.Card)
+	}
+}
+
+// parseAttribute accepts a tile number from the list or an attribute name
+func parseAttribute(s string, edition *Edition, availableTokens []Attribute) (Attribute, bool) {
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 1 || n > len(availableTokens) {
+			return 0, false
+		}
+		return availableTokens[n-1], true
+	}
+	for _, attr := range availableTokens {
+		if strings.EqualFold(edition.AttributeName(attr), s) {
+			return attr, true
+		}
+	}
+	return 0, false
+}
+
+// parseAction reads one of the manipulations the rules allow, such as
+// "flip N" or "swap N M", with 1-based slot numbers and player numbers as
+// the table shows them
+func parseAction(s string, g *Game) (Action, error) {
+	names := g.Rules.ActionNames()
+	fields := strings.Fields(strings.ToLower(s))
+	if len(fields) == 0 || !slices.Contains(names, fields[0]) || len(fields)-1 != len(actionKinds[fields[0]].Params()) {
+		return Action{}, fmt.Errorf("enter %s", actionUsage(names))
+	}
+
+	kind := actionKinds[fields[0]]
+	args := []int{}
+	for i, param := range kind.Params() {
+		n, err := strconv.Atoi(fields[i+1])
+		if param == "player" {
+			if err != nil || n < 0 || n >= g.NumPlayers {
+				return Action{}, fmt.Errorf("players go from 0 to %d", g.NumPlayers-1)
+			}
+			args = append(args, n)
+			continue
+		}
+		if err != nil || n < 1 || n > len(g.Board.Slots) {
+			return Action{}, fmt.Errorf("slot numbers go from 1 to %d", len(g.Board.Slots))
+		}
+		args = append(args, n-1)
+	}
+	return kind.Build(args), nil
+}
+
+// repeatReminder says what the no-repeat rule rules out before a player
+// chooses a manipulation, or "" if it rules out nothing yet
+func repeatReminder(g *Game, previousAction *Action) string {
+	history := g.Manipulations
+	switch g.Rules.NoRepeatRule() {
+	case NoRepeatPrevious:
+		if previousAction != nil {
+			return fmt.Sprintf("The previous player chose to %s, so you may not.", previousAction)
+		}
+	case NoRepeatRound:
+		if len(history) > 0 {
+			made := make([]string, len(history))
+			for i, m := range history {
+				made[i] = m.Action.String()
+			}
+			return fmt.Sprintf("Already chosen this round, so nobody may choose again: %s.", strings.Join(made, ", "))
+		}
+	case NoRepeatUndo:
+		if last := len(history) - 1; last >= 0 && !sameBoard(history[last].Before, history[last].After) {
+			return fmt.Sprintf("The previous player chose to %s, so you may not undo it.", history[last].Action)
+		}
+	case NoRepeatSlot1:
+		for _, m := range history {
+			if m.changes(0) {
+				return "Slot 1 has already been changed this round, so you may not change it."
+			}
+		}
+	}
+	return ""
+}
+
+// illegalActionMessage explains why a well-formed action is not allowed
+func illegalActionMessage(g *Game, action Action, previousAction *Action) string {
+	rule := g.Rules.NoRepeatRule()
+	if rule != NoRepeatPrevious && repeatForbidden(rule, g.Manipulations, g.Board, action) {
+		switch rule {
+		case NoRepeatRound:
+			return fmt.Sprintf("Someone has already chosen to %s this round.", action)
+		case NoRepeatUndo:
+			return fmt.Sprintf("You may not undo the previous player's action (%s).", previousAction)
+		case NoRepeatSlot1:
+			return "Slot 1 has already been changed this round."
+		}
+	}
+	if rule == NoRepeatPrevious && previousAction != nil && actionsMatch(action, *previousAction) {
+		return fmt.Sprintf("You may not copy the previous player's action (%s).", previousAction)
+	}
+	return fmt.Sprintf("You may not %s now.", action)
+}
+
+// parseDiscards reads n distinct 1-based bee numbers, such as "1 4"
+func parseDiscards(s string, n int, handSize int) ([]int, error) {
+	fields := strings.Fields(s)
+	if len(fields) != n {
+		return nil, fmt.Errorf("enter %d bee numbers from 1 to %d", n, handSize)
+	}
+	discards := []int{}
+	taken := make(map[int]bool)
+	for _, f := range fields {
+		i, err := strconv.Atoi(f)
+		if err != nil || i < 1 || i > handSize || taken[i] {
+			return nil, fmt.Errorf("enter %d different bee numbers from 1 to %d", n, handSize)
+		}
+		taken[i] = true
+		discards = append(discards, i-1)
+	}
+	return discards, nil
+}
+
+// RunInteractiveGame plays one verbose game with people in the given seats
+// and newAgent in the rest
+func RunInteractiveGame(numPlayers int, humanSeats []int, newAgent AgentFactory) {
+	in := bufio.NewScanner(os.Stdin)
+	game := NewGame(numPlayers, true)
+	game.HideReasoning = true
+	game.Agents = make([]Agent, numPlayers)
+	for i := range game.Agents {
+		game.Agents[i] = newAgent()
+	}
+	for _, seat := range humanSeats {
+		game.Agents[seat] = NewHumanAgent(in, os.Stdout)
+	}
+
+	fmt.Printf("Playing with %d players. Humans: %s\n", numPlayers, joinInts(humanSeats))
+	game.Run()
+}
+
+// parseSeats reads a comma-separated list of distinct seat numbers
+func parseSeats(s string, numPlayers int) ([]int, error) {
+	seats := []int{}
+	taken := make(map[int]bool)
+	for _, field := range strings.Split(s, ",") {
+		seat, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || seat < 0 || seat >= numPlayers {
+			return nil, fmt.Errorf("seats go from 0 to %d, got %q", numPlayers-1, field)
+		}
+		if taken[seat] {
+			return nil, fmt.Errorf("seat %d listed twice", seat)
+		}
+		taken[seat] = true
+		seats = append(seats, seat)
+	}
+	return seats, nil
+}
+
+// joinInts formats seat numbers for messages
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ", ")
+}
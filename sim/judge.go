@@ -0,0 +1,213 @@
+package main
+
+import "fmt"
+
+// Ways the Judge picks a trick's winner, set by RuleConfig.Judge
+const (
+	JudgeSlots  = "slots"  // Slot by slot from Slot 1, eliminating cards that miss (the published rule)
+	JudgePoints = "points" // Each matched slot scores its weight; the highest total wins
+)
+
+// Ways the points judge settles equal totals, set by RuleConfig.JudgeTieBreak
+const (
+	TieBreakSlots    = "slots"    // Judge the tied cards slot by slot, as the published Judge does
+	TieBreakLeader   = "leader"   // The tied card played closest to the leader
+	TieBreakTrailing = "trailing" // The tied player with the lowest score
+)
+
+// What happens to cards the Judge cannot separate, set by RuleConfig.Ties.
+// With a single deck no two bees are alike, so the published Judge only
+// leaves ties when a slot is empty; a second deck makes them common.
+const (
+	TiesClosest  = "closest"  // The tied card played closest to the leader wins (the published rule)
+	TiesFurthest = "furthest" // The tied card played furthest from the leader wins
+	TiesSplit    = "split"    // The tied players share the trick's points; the tie-break decides who takes the cards and leads, without winning the trick
+	TiesReplay   = "replay"   // The tied players present another bee each, until one wins or a hand runs out
+)
+
+var (
+	judgeNames    = []string{JudgeSlots, JudgePoints}
+	tieBreakNames = []string{TieBreakSlots, TieBreakLeader, TieBreakTrailing}
+	tiesNames     = []string{TiesClosest, TiesFurthest, TiesSplit, TiesReplay}
+)
+
+// defaultWeights gives the points judge's slots falling weights: the number
+// of slots for Slot 1, down to 1 for the last, so no slot dominates the
+// others as Slot 1 does under the published Judge
+func defaultWeights(numSlots int) []int {
+	weights := make([]int, numSlots)
+	for i := range weights {
+		weights[i] = numSlots - i
+	}
+	return weights
+}
+
+// cardPoints totals the weights of the slots the card matches
+func (g *Game) cardPoints(card Card) int {
+	weights := g.Rules.Weights(len(g.Board.Slots))
+	points := 0
+	for i, token := range g.Board.Slots {
+		if token != nil && card.Matches(token.Attribute, token.Value) {
+			points += weights[i]
+		}
+	}
+	return points
+}
+
+// maxCardScore is scoreCard of a card matching every slot
+func (g *Game) maxCardScore() int {
+	maxScore := g.Edition().MaxScore()
+	if g.Rules.JudgeName() != JudgePoints {
+		return maxScore
+	}
+	total := 0
+	for _, weight := range g.Rules.Weights(len(g.Board.Slots)) {
+		total += weight
+	}
+	return total*(maxScore+1) + maxScore
+}
+
+// strength puts a scoreCard value on the published Judge's scale, from 0 to
+// the edition's MaxScore, for agents whose weights were tuned on it
+func (g *Game) strength(score int) float64 {
+	return float64(score) * float64(g.Edition().MaxScore()) / float64(g.maxCardScore())
+}
+
+// slotShare estimates the share of tricks a slot decides: under the
+// published Judge, Slot 1 decides about half, Slot 2 a quarter, and so on;
+// under the points judge, each slot's share of the total weight
+func (g *Game) slotShare(slotIdx int) float64 {
+	if g.Rules.JudgeName() != JudgePoints {
+		return float64(int(1)<<(len(g.Board.Slots)-1-slotIdx)) / float64(g.Edition().MaxScore())
+	}
+	weights := g.Rules.Weights(len(g.Board.Slots))
+	total := 0
+	for _, weight := range weights {
+		total += weight
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(weights[slotIdx]) / float64(total)
+}
+
+// judgePoints totals every active card's points and keeps those with the
+// highest total
+func (g *Game) judgePoints(plays []Play, active []int) []int {
+	totals := make([]int, g.NumPlayers)
+	best := -1
+	for _, playIdx := range active {
+		points := g.cardPoints(plays[playIdx].Card)
+		totals[plays[playIdx].PlayerID] = points
+		best = max(best, points)
+		if g.Verbose {
+			fmt.Printf("  Player %d scores %d points\n", plays[playIdx].PlayerID, points)
+		}
+	}
+
+	top, eliminated := []int{}, []int{}
+	for _, playIdx := range active {
+		if totals[plays[playIdx].PlayerID] == best {
+			top = append(top, playIdx)
+		} else {
+			eliminated = append(eliminated, playIdx)
+		}
+	}
+	g.emit(Event{
+		Kind:       EventJudgeTotals,
+		Totals:     totals,
+		Eliminated: playerIDs(plays, eliminated),
+		Survivors:  playerIDs(plays, top),
+	})
+	return top
+}
+
+// breakTie picks the winner among cards the Judge could not separate.
+// active is in play order, so its first card is the closest to the leader.
+// Under the split and replay tie rules it picks who takes the cards and the
+// lead once nothing else will separate them.
+func (g *Game) breakTie(plays []Play, active []int) int {
+	if g.Rules.JudgeName() == JudgePoints && g.Rules.TieBreakName() == TieBreakTrailing {
+		winnerIdx := active[0]
+		for _, playIdx := range active {
+			if g.Players[plays[playIdx].PlayerID].Score < g.Players[plays[winnerIdx].PlayerID].Score {
+				winnerIdx = playIdx
+			}
+		}
+		if g.Verbose {
+			fmt.Printf("\nTie-breaker: Multiple cards survived. Winner has the lowest score.\n")
+		}
+		return winnerIdx
+	}
+
+	if g.Rules.TiesName() == TiesFurthest {
+		if g.Verbose {
+			fmt.Printf("\nTie-breaker: Multiple cards survived. Winner is furthest from leader.\n")
+		}
+		return active[len(active)-1]
+	}
+
+	// Tie-breaker: closest to leader in play order
+	if g.Verbose {
+		fmt.Printf("\nTie-breaker: Multiple cards survived. Winner is closest to leader.\n")
+	}
+	return active[0]
+}
+
+// canReplay reports whether every tied player has a bee left to present
+// again under the replay tie rule
+func (g *Game) canReplay(plays []Play, active []int) bool {
+	for _, playIdx := range active {
+		if len(g.Players[plays[playIdx].PlayerID].Hand) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// splitPoints shares a trick's points equally among the tied players under
+// the split tie rule, and returns each player's share. What does not divide
+// evenly goes into the pot for the next trick of the hand, so a one-point
+// trick tied two ways is won by whoever wins the next one. After the last
+// trick of the hand, or in sudden death where any trick may be the last, the
+// player taking the cards scores it instead.
+func (g *Game) splitPoints(plays []Play, active []int, points int, takerID int) []int {
+	shares := make([]int, g.NumPlayers)
+	for _, playIdx := range active {
+		shares[plays[playIdx].PlayerID] = points / len(active)
+	}
+	remainder := points % len(active)
+	last := g.minHandSize() == 0 || g.SuddenDeath
+	if last {
+		shares[takerID] += remainder
+	} else {
+		g.Pot = remainder
+	}
+	if g.Verbose {
+		fmt.Printf("\nTie: Players %s share %d points", joinInts(playerIDs(plays, active)), points)
+		if remainder > 0 && last {
+			fmt.Printf(", Player %d scoring the %d left over", takerID, remainder)
+		} else if remainder > 0 {
+			fmt.Printf(", carrying %d to the next trick", remainder)
+		}
+		fmt.Println(".")
+	}
+	return shares
+}
+
+// settlePot gives points a split tie left for a trick that will not be
+// played, as when a forfeit ends the game, to the player who took the split
+// trick's bees and so leads
+func (g *Game) settlePot() {
+	if g.Pot == 0 {
+		return
+	}
+	if g.Verbose {
+		fmt.Printf("Player %d scores the %d points left over from the split trick.\n", g.CurrentLeader, g.Pot)
+	}
+	g.Players[g.CurrentLeader].Score += g.Pot
+	if g.Stats != nil {
+		g.Stats.TrickPoints += g.Pot
+	}
+	g.Pot = 0
+}
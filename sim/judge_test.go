@@ -0,0 +1,113 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// tieGame sets up a two-deck game whose board matches the bee with every
+// value 0 in every slot, and returns that bee, two copies of which tie, and
+// the bee with every value 1, which loses at Slot 1
+func tieGame(numPlayers int, ties string) (*Game, Card, Card) {
+	game := NewSeededGame(numPlayers, 1, false)
+	game.Rules.Decks = 2
+	game.Rules.Ties = ties
+	game.Stats = NewStats(numPlayers)
+	game.CurrentLeader = 0
+	game.TrickNumber = 2
+	for slot := range game.Board.Slots {
+		game.Board.Slots[slot] = &AttributeToken{Attribute: Attribute(slot), Edition: printedEdition}
+	}
+	for range game.Players {
+		game.Agents = append(game.Agents, scriptedAgent{})
+	}
+
+	var zeros, ones Card
+	zeros.Edition, ones.Edition = printedEdition, printedEdition
+	for attr := range printedEdition.NumAttributes() {
+		ones.Attributes[attr] = 1
+	}
+	return game, zeros, ones
+}
+
+func TestSplitTies(t *testing.T) {
+	game, zeros, ones := tieGame(3, TiesSplit)
+	for _, p := range game.Players {
+		p.Hand = []Card{ones} // A trick follows
+	}
+	game.LastTrickWinner, game.ConsecutiveWins = 0, 1
+
+	// Players 0 and 1 share the one point, which does not divide, so it
+	// waits for the next trick. Player 0, nearest the leader, takes the bees
+	// and the lead without winning the trick.
+	plays := []Play{{0, zeros}, {1, zeros}, {2, ones}}
+	if taker := game.RunFilterPhase(plays); taker != 0 {
+		t.Errorf("player %d took the split trick, want player 0", taker)
+	}
+	for _, p := range game.Players {
+		if p.Score != 0 || p.TricksWon != 0 {
+			t.Errorf("player %d has %d points and %d tricks after the split, want none", p.ID, p.Score, p.TricksWon)
+		}
+	}
+	if game.Pot != 1 || len(game.Players[0].ScorePile) != 3 {
+		t.Errorf("pot %d and %d bees taken, want 1 and 3", game.Pot, len(game.Players[0].ScorePile))
+	}
+	stats := game.Stats
+	if stats.SplitTricks != 1 || stats.LeaderRepeats != 0 || stats.TwoStreaksByPlayer[0] != 0 || slices.Max(stats.TricksByPlayer) != 0 {
+		t.Errorf("the split trick was counted as won: %+v", stats)
+	}
+
+	// The next trick's winner takes the pot as well
+	plays = []Play{{0, ones}, {1, zeros}, {2, ones}}
+	if winner := game.RunFilterPhase(plays); winner != 1 || game.Players[1].Score != 2 || game.Pot != 0 {
+		t.Errorf("player %d won with %d points and %d left in the pot, want player 1 with 2 and none", winner, game.Players[1].Score, game.Pot)
+	}
+	if game.ConsecutiveWins != 1 || game.LastTrickWinner != 1 {
+		t.Errorf("streak of %d for player %d, want a new one for player 1", game.ConsecutiveWins, game.LastTrickWinner)
+	}
+
+	// No trick follows the last of the hand, so its taker scores what is left
+	for _, p := range game.Players {
+		p.Hand = nil
+	}
+	plays = []Play{{0, ones}, {1, zeros}, {2, zeros}}
+	if taker := game.RunFilterPhase(plays); taker != 1 || game.Players[1].Score != 3 || game.Players[2].Score != 0 || game.Pot != 0 {
+		t.Errorf("player %d took the last trick; scores %d and %d with %d in the pot, want player 1 scoring 3", taker, game.Players[1].Score, game.Players[2].Score, game.Pot)
+	}
+}
+
+func TestSplitPotSettledAtGameEnd(t *testing.T) {
+	game, _, _ := tieGame(2, TiesSplit)
+	game.Pot = 1
+	game.CurrentLeader = 1
+	game.settlePot()
+	if game.Players[1].Score != 1 || game.Pot != 0 {
+		t.Errorf("player 1 has %d points with %d in the pot, want the pot's point", game.Players[1].Score, game.Pot)
+	}
+}
+
+func TestReplayTies(t *testing.T) {
+	game, zeros, ones := tieGame(2, TiesReplay)
+
+	// The tied players present again; only the new bees are judged, and the
+	// winner takes all four
+	game.Players[0].Hand = []Card{ones}
+	beatsOnes := ones
+	beatsOnes.Attributes[0] = 0 // Matches Slot 1
+	game.Players[1].Hand = []Card{beatsOnes}
+	if winner := game.RunFilterPhase([]Play{{0, zeros}, {1, zeros}}); winner != 1 {
+		t.Errorf("player %d won the replay, want player 1", winner)
+	}
+	if p := game.Players[1]; p.TricksWon != 1 || p.Score != 1 || len(p.ScorePile) != 4 {
+		t.Errorf("the replay's winner has %d tricks, %d points and %d bees, want 1, 1 and 4", p.TricksWon, p.Score, len(p.ScorePile))
+	}
+	if game.Stats.Replays != 1 {
+		t.Errorf("%d replays counted, want 1", game.Stats.Replays)
+	}
+
+	// With no bees left to present, the tie goes to the player nearest the
+	// leader
+	if winner := game.RunFilterPhase([]Play{{0, zeros}, {1, zeros}}); winner != 0 {
+		t.Errorf("player %d won with nothing left to replay, want player 0", winner)
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+)
+
+// Likelihood multipliers used when guessing which cards an opponent holds.
+// The drafting AI puts the value it holds more of in Slot 1 and buries the
+// value it holds fewer of in the lower slots.
+const (
+	slot1DraftLikelihood  = 2.0
+	buriedDraftLikelihood = 1.5
+)
+
+// LeaderAgent drafts and presents greedily, but in the Manipulate phase it
+// targets the opponent closest to winning. Among the actions that cost its own
+// best card no more than Tolerance points, it picks the one that minimises the
+// leader's estimated chance of taking the next trick.
+type LeaderAgent struct {
+	Tolerance int // On the published Judge's scale, whichever judge is in play
+
+	Targeted int         // Manipulations that differed from the greedy choice
+	Targets  map[int]int // How often each opponent was targeted
+}
+
+// NewLeaderAgent creates a leader-targeting agent with the same tolerance the
+// web app's defensive strategy uses
+func NewLeaderAgent() *LeaderAgent {
+	return &LeaderAgent{Tolerance: 4, Targets: map[int]int{}}
+}
+
+func (a *LeaderAgent) ChooseToken(g *Game, player *Player, availableTokens []Attribute, slotIdx int) (Attribute, int) {
+	return g.selectBestToken(player, availableTokens, slotIdx)
+}
+
+func (a *LeaderAgent) ChooseCard(g *Game, player *Player) int {
+	return g.selectBestCard(player)
+}
+
+func (a *LeaderAgent) ChooseAction(g *Game, player *Player, previousAction *Action) Action {
+	target := g.leadingOpponent(player.ID)
+	if target == -1 || len(player.Hand) == 0 {
+		return g.selectBestAction(player, previousAction)
+	}
+
+	// Score our own best card after each action to find the greedy choice
+	actions := g.legalActions(player, previousAction)
+	ownBest := make([]int, len(actions))
+	greedyIdx := 0
+	for i, action := range actions {
+		ownBest[i], _ = g.scoreActionOutcome(player, action)
+		if ownBest[i] > ownBest[greedyIdx] {
+			greedyIdx = i
+		}
+	}
+
+	cards, weights, known := a.likelyCards(g, player, target)
+	handSize := len(g.Players[target].Hand) - len(known)
+
+	// Only move away from the greedy action if it strictly hurts the leader
+	bestIdx := greedyIdx
+	bestChance := g.opponentWinChance(actions[greedyIdx], ownBest[greedyIdx], cards, weights, handSize, known)
+	for i, action := range actions {
+		if g.strength(ownBest[i]) < g.strength(ownBest[greedyIdx])-float64(a.Tolerance) {
+			continue
+		}
+		chance := g.opponentWinChance(action, ownBest[i], cards, weights, handSize, known)
+		if chance < bestChance || (chance == bestChance && ownBest[i] > ownBest[bestIdx]) {
+			bestChance = chance
+			bestIdx = i
+		}
+	}
+
+	if bestIdx != greedyIdx {
+		a.Targeted++
+		a.Targets[target]++
+	}
+
+	if g.showReasoning() {
+		fmt.Printf("  (Player %d AI: targeting Player %d, leader win chance %.0f%%, choosing %s)\n",
+			player.ID, target, bestChance*100, actions[bestIdx])
+	}
+
+	return actions[bestIdx]
+}
+
+// likelyCards lists every card the target might still hold, weighted by how
+// consistent it is with the tokens the target drafted this hand, and the
+// cards the player has peeked at in the target's hand. With several decks,
+// a card is weighted by the copies of it the player has not seen.
+func (a *LeaderAgent) likelyCards(g *Game, player *Player, target int) ([]Card, []float64, []Card) {
+	copies := g.Rules.DeckCount()
+	seen := make(map[Card]int)
+	for _, card := range player.Hand {
+		seen[card]++
+	}
+	for _, card := range g.Revealed {
+		seen[card]++
+	}
+	known := []Card{}
+	for _, peek := range player.knownHeld() {
+		if peek.Holder == target && seen[peek.Card] < copies {
+			known = append(known, peek.Card)
+		}
+		seen[peek.Card]++
+	}
+
+	cards := []Card{}
+	weights := []float64{}
+	for _, card := range CreateDeck(g.Edition()) {
+		unseen := copies - seen[card]
+		if unseen <= 0 {
+			continue
+		}
+
+		weight := float64(unseen)
+		for _, pick := range g.DraftPicks {
+			if pick.PlayerID != target {
+				continue
+			}
+			matches := card.Matches(pick.Token.Attribute, pick.Token.Value)
+			if pick.SlotIndex == 0 && matches {
+				weight *= slot1DraftLikelihood
+			} else if pick.SlotIndex != 0 && !matches {
+				weight *= buriedDraftLikelihood
+			}
+		}
+
+		cards = append(cards, card)
+		weights = append(weights, weight)
+	}
+
+	return cards, weights, known
+}
+
+// opponentWinChance estimates the chance that an opponent holding the known
+// cards and handSize more drawn from the weighted candidates has one that
+// beats ownBest once the action has been applied
+func (g *Game) opponentWinChance(action Action, ownBest int, cards []Card, weights []float64, handSize int, known []Card) float64 {
+	saved := g.Board
+	g.Board = saved.clone()
+	g.Board.apply(action)
+	defer func() { g.Board = saved }()
+
+	for _, card := range known {
+		if g.scoreCard(card) > ownBest {
+			return 1
+		}
+	}
+
+	total, beating := 0.0, 0.0
+	for i, card := range cards {
+		total += weights[i]
+		if g.scoreCard(card) > ownBest {
+			beating += weights[i]
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+
+	return 1 - math.Pow(1-beating/total, float64(handSize))
+}
+
+// leadingOpponent returns the opponent with the highest score, or -1 if nobody
+// is ahead of playerID. Ties go to the first such opponent clockwise.
+func (g *Game) leadingOpponent(playerID int) int {
+	leader := -1
+	maxScore := g.Players[playerID].Score
+	for i := 1; i < g.NumPlayers; i++ {
+		opponent := g.Players[(playerID+i)%g.NumPlayers]
+		if opponent.Score > maxScore {
+			maxScore = opponent.Score
+			leader = opponent.ID
+		}
+	}
+	return leader
+}
+
+// RunKingmakingReport plays each seed twice under the rules, once with every
+// seat greedy and once with every seat targeting the leader, and reports how
+// often the targeting changed who won
+func RunKingmakingReport(numPlayers int, numGames int, rules RuleConfig) {
+	fmt.Printf("Running %d paired games with %d players and the %s judge...\n", numGames, numPlayers, rules.JudgeName())
+
+	targeted := 0
+	changed := 0
+	winnerTargeted := 0
+	winnerDethroned := 0
+	wonByTargeter := 0
+	wonByBystander := 0
+
+	for i := 0; i < numGames; i++ {
+		seed := rand.Int63()
+
+		baseline := NewSeededGame(numPlayers, seed, false)
+		baseline.Rules = rules
+		baselineWinner := baseline.Run()
+
+		variant := NewSeededGame(numPlayers, seed, false)
+		variant.Rules = rules
+		agents := make([]*LeaderAgent, numPlayers)
+		for p := range agents {
+			agents[p] = NewLeaderAgent()
+			variant.Agents = append(variant.Agents, agents[p])
+		}
+		winner := variant.Run()
+
+		for _, agent := range agents {
+			targeted += agent.Targeted
+		}
+		if winner != baselineWinner {
+			changed++
+		}
+
+		// Did targeting the would-be winner take the game away from them?
+		wasTargeted := false
+		for _, agent := range agents {
+			if agent.Targets[baselineWinner] > 0 {
+				wasTargeted = true
+			}
+		}
+		if wasTargeted {
+			winnerTargeted++
+			if winner != baselineWinner {
+				winnerDethroned++
+				if agents[winner].Targets[baselineWinner] > 0 {
+					wonByTargeter++
+				} else {
+					wonByBystander++
+				}
+			}
+		}
+
+		if (i+1)%100 == 0 {
+			fmt.Printf("  Completed %d/%d games\n", i+1, numGames)
+		}
+	}
+
+	fmt.Printf("\n=== KINGMAKING REPORT FOR %d-PLAYER GAMES, %s JUDGE (%d paired games) ===\n",
+		numPlayers, strings.ToUpper(rules.JudgeName()), numGames)
+	fmt.Println()
+	fmt.Printf("Leader-targeting manipulations: %d (%.1f per game)\n", targeted, float64(targeted)/float64(numGames))
+	fmt.Printf("Games with a different winner: %d (%.1f%%)\n", changed, float64(changed)/float64(numGames)*100)
+	if winnerTargeted > 0 {
+		fmt.Printf("Games where the greedy winner was targeted: %d, lost %d (%.1f%%)\n",
+			winnerTargeted, winnerDethroned, float64(winnerDethroned)/float64(winnerTargeted)*100)
+		fmt.Printf("  Won instead by a player who targeted them: %d\n", wonByTargeter)
+		fmt.Printf("  Won instead by a bystander (kingmade): %d\n", wonByBystander)
+	}
+	fmt.Println()
+}
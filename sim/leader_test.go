@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+// leaderTargets plays seeded games with every seat targeting the leader and
+// counts the manipulations that differed from the greedy choice
+func leaderTargets(rules RuleConfig, numGames int) int {
+	targeted := 0
+	for seed := int64(1); seed <= int64(numGames); seed++ {
+		game := NewSeededGame(4, seed, false)
+		game.Rules = rules
+		agents := make([]*LeaderAgent, game.NumPlayers)
+		for i := range agents {
+			agents[i] = NewLeaderAgent()
+			game.Agents = append(game.Agents, agents[i])
+		}
+		game.Run()
+		for _, agent := range agents {
+			targeted += agent.Targeted
+		}
+	}
+	return targeted
+}
+
+// TestLeaderToleranceUnderPointsJudge checks that the agent's tolerance is
+// measured on the same scale whichever judge is in play, so that it targets
+// the leader about as often under the points judge, whose card scores run
+// far higher
+func TestLeaderToleranceUnderPointsJudge(t *testing.T) {
+	points := DefaultRules()
+	points.Judge = JudgePoints
+	slots, weighted := leaderTargets(DefaultRules(), 20), leaderTargets(points, 20)
+	if weighted < slots/2 {
+		t.Fatalf("%d leader-targeting manipulations under the points judge, against %d under the published one", weighted, slots)
+	}
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+)
+
+const defaultWeightsFile = "weights.json"
+
+// manipulationFeatures names the inputs to the learned value function. Every
+// feature describes the board after a candidate manipulation from the point
+// of view of the manipulating player.
+var manipulationFeatures = []string{
+	"bias",
+	"best_card",
+	"second_card",
+	"mean_card",
+	"slot1_matches",
+	"slot2_matches",
+	"slot3_matches",
+	"slot4_matches",
+	"slot5_matches",
+	"slot6_matches",
+	"tricks_left",
+	"own_tricks",
+	"max_opponent_tricks",
+	"trick_lead",
+	"manipulations_after",
+	"best_card_x_manipulations_after",
+}
+
+// ValueModel is a linear estimate of how many tricks a player will win
+// before their next manipulation, given the board they leave behind
+type ValueModel struct {
+	Features []string  `json:"features"`
+	Weights  []float64 `json:"weights"`
+	Games    int       `json:"games"` // Self-play games used for training
+}
+
+// NewValueModel creates an untrained model
+func NewValueModel() *ValueModel {
+	return &ValueModel{
+		Features: manipulationFeatures,
+		Weights:  make([]float64, len(manipulationFeatures)),
+	}
+}
+
+// LoadValueModel reads weights saved by SaveValueModel
+func LoadValueModel(path string) (*ValueModel, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var model ValueModel
+	if err := json.Unmarshal(data, &model); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if len(model.Weights) != len(manipulationFeatures) {
+		return nil, fmt.Errorf("%s: expected %d weights, found %d", path, len(manipulationFeatures), len(model.Weights))
+	}
+	return &model, nil
+}
+
+// SaveValueModel writes the weights as JSON
+func SaveValueModel(path string, model *ValueModel) error {
+	data, err := json.MarshalIndent(model, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Value estimates the return of a feature vector
+func (m *ValueModel) Value(features []float64) float64 {
+	value := 0.0
+	for i, f := range features {
+		value += m.Weights[i] * f
+	}
+	return value
+}
+
+// LearnedAgent drafts and presents greedily and chooses the manipulation
+// whose resulting board its value model rates highest. With a positive Alpha
+// it also updates the model by TD(0) as it plays.
+type LearnedAgent struct {
+	Model *ValueModel
+
+	// Training parameters; zero for a frozen agent
+	Alpha   float64 // Learning rate
+	Gamma   float64 // Discount between consecutive manipulations
+	Epsilon float64 // Chance of exploring a random action
+	rng     *rand.Rand
+
+	lastFeatures []float64 // Afterstate of our previous manipulation
+	lastScore    int       // Score we had when we made it
+}
+
+func (a *LearnedAgent) ChooseToken(g *Game, player *Player, availableTokens []Attribute, slotIdx int) (Attribute, int) {
+	// A new hand has started, so the previous hand's last manipulation
+	// has received all the reward it ever will
+	a.finish(player)
+	return g.selectBestToken(player, availableTokens, slotIdx)
+}
+
+func (a *LearnedAgent) ChooseCard(g *Game, player *Player) int {
+	return g.selectBestCard(player)
+}
+
+func (a *LearnedAgent) ChooseAction(g *Game, player *Player, previousAction *Action) Action {
+	actions := g.legalActions(player, previousAction)
+
+	bestIdx := 0
+	var bestFeatures []float64
+	bestValue := 0.0
+	for i, action := range actions {
+		features := g.afterstateFeatures(player, action)
+		value := a.Model.Value(features)
+		if bestFeatures == nil || value > bestValue {
+			bestIdx, bestFeatures, bestValue = i, features, value
+		}
+	}
+
+	if a.Alpha > 0 && a.rng.Float64() < a.Epsilon {
+		bestIdx = a.rng.Intn(len(actions))
+		bestFeatures = g.afterstateFeatures(player, actions[bestIdx])
+		bestValue = a.Model.Value(bestFeatures)
+	}
+
+	if a.Alpha > 0 {
+		if a.lastFeatures != nil {
+			reward := float64(player.Score - a.lastScore)
+			a.update(reward + a.Gamma*bestValue)
+		}
+		a.lastFeatures = bestFeatures
+		a.lastScore = player.Score
+	}
+
+	if g.showReasoning() {
+		fmt.Printf("  (Player %d AI: learned value %.2f, choosing %s)\n", player.ID, bestValue, actions[bestIdx])
+	}
+
+	return actions[bestIdx]
+}
+
+func (a *LearnedAgent) ScoreTokens(g *Game, player *Player, availableTokens []Attribute, slotIdx int) []float64 {
+	return g.tokenScores(player, availableTokens, slotIdx, 1)
+}
+
+func (a *LearnedAgent) ScoreCards(g *Game, player *Player) []float64 {
+	return GreedyAgent{}.ScoreCards(g, player)
+}
+
+// ScoreActions rates each action by the learned value of the board it leaves
+func (a *LearnedAgent) ScoreActions(g *Game, player *Player, actions []Action) []float64 {
+	scores := make([]float64, len(actions))
+	for i, action := range actions {
+		scores[i] = a.Model.Value(g.afterstateFeatures(player, action))
+	}
+	return scores
+}
+
+// finish makes the terminal update for the last manipulation of a hand or game
+func (a *LearnedAgent) finish(player *Player) {
+	if a.Alpha == 0 || a.lastFeatures == nil {
+		return
+	}
+	a.update(float64(player.Score - a.lastScore))
+	a.lastFeatures = nil
+}
+
+// update moves the value of the previous afterstate towards target
+func (a *LearnedAgent) update(target float64) {
+	delta := target - a.Model.Value(a.lastFeatures)
+	for i, f := range a.lastFeatures {
+		a.Model.Weights[i] += a.Alpha * delta * f
+	}
+}
+
+// afterstateFeatures describes the board a player would leave behind after
+// taking an action
+func (g *Game) afterstateFeatures(player *Player, action Action) []float64 {
+	saved := g.Board
+	g.Board = saved.clone()
+	g.Board.apply(action)
+	defer func() { g.Board = saved }()
+
+	features := make([]float64, 0, len(manipulationFeatures))
+	features = append(features, 1)
+
+	// Card quality, normalised by the maximum score (63 with six slots
+	// under the published Judge)
+	maxScore := float64(g.maxCardScore())
+	best, second, total := 0, 0, 0
+	for _, card := range player.Hand {
+		score := g.scoreCard(card)
+		total += score
+		if score > best {
+			best, second = score, best
+		} else if score > second {
+			second = score
+		}
+	}
+	mean := 0.0
+	if len(player.Hand) > 0 {
+		mean = float64(total) / float64(len(player.Hand)) / maxScore
+	}
+	features = append(features, float64(best)/maxScore, float64(second)/maxScore, mean)
+
+	// Share of the hand matching each of the first six slots; editions with
+	// fewer leave the rest at zero, and later slots rarely decide a trick
+	for i := 0; i < 6; i++ {
+		matches := 0
+		var token *AttributeToken
+		if i < len(g.Board.Slots) {
+			token = g.Board.Slots[i]
+		}
+		for _, card := range player.Hand {
+			if token != nil && card.Matches(token.Attribute, token.Value) {
+				matches++
+			}
+		}
+		share := 0.0
+		if len(player.Hand) > 0 {
+			share = float64(matches) / float64(len(player.Hand))
+		}
+		features = append(features, share)
+	}
+
+	// Game situation
+	maxOpponent := 0
+	for _, p := range g.Players {
+		if p.ID != player.ID && p.Score > maxOpponent {
+			maxOpponent = p.Score
+		}
+	}
+	position := (player.ID - g.CurrentLeader + g.NumPlayers) % g.NumPlayers
+	after := float64(g.NumPlayers-1-position) / float64(g.NumPlayers)
+	// Scaled by the rules' hand size and target so that a model carries
+	// across rule sets
+	handSize := float64(g.Rules.HandSize)
+	target := float64(g.Rules.Target(g.NumPlayers))
+	features = append(features,
+		float64(len(player.Hand))/handSize,
+		float64(player.Score)/target,
+		float64(maxOpponent)/target,
+		float64(player.Score-maxOpponent)/target,
+		after,
+		float64(best)/maxScore*after,
+	)
+
+	return features
+}
+
+// TrainManipulationPolicy learns a value model by self-play, cycling
+// through every player count with all seats sharing one model
+func TrainManipulationPolicy(numGames int, seed int64) *ValueModel {
+	model := NewValueModel()
+	rng := rand.New(rand.NewSource(seed))
+
+	fmt.Printf("Training manipulation policy over %d self-play games...\n", numGames)
+
+	for i := 0; i < numGames; i++ {
+		// Explore a lot early on and settle down later
+		progress := float64(i) / float64(numGames)
+		epsilon := 0.2 - 0.18*progress
+
+		numPlayers := 2 + i%4
+		game := NewSeededGame(numPlayers, rng.Int63(), false)
+		learners := make([]*LearnedAgent, numPlayers)
+		for p := range learners {
+			learners[p] = &LearnedAgent{
+				Model:   model,
+				Alpha:   0.01,
+				Gamma:   0.9,
+				Epsilon: epsilon,
+				rng:     rng,
+			}
+			game.Agents = append(game.Agents, learners[p])
+		}
+
+		game.Run()
+		for p, learner := range learners {
+			learner.finish(game.Players[p])
+		}
+		model.Games++
+
+		if (i+1)%1000 == 0 {
+			fmt.Printf("  Completed %d/%d games\n", i+1, numGames)
+		}
+	}
+
+	fmt.Println("\nLearned weights:")
+	for i, name := range model.Features {
+		fmt.Printf("  %-32s %8.4f\n", name, model.Weights[i])
+	}
+
+	return model
+}
@@ -0,0 +1,664 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	mathrand "math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// The lobby server lets people on a LAN play each other over WebSockets.
+// Clients connect to /ws and exchange JSON messages with a "type" field.
+//
+// Client to server:
+//
+//	{"type": "list"}
+//	{"type": "create", "name": "Friday", "players": 4, "rules": {...}}
+//	{"type": "join", "lobby": "1", "seat": 2, "name": "Ada"}   (seat optional)
+//	{"type": "spectate", "lobby": "1"}
+//	{"type": "reconnect", "lobby": "1", "token": "..."}
+//	{"type": "start", "agent": "medium"}   (AI for empty seats, default greedy)
+//	{"type": "move", "move": {...}}        (a Move, as in the HTTP API)
+//	{"type": "leave"}
+//
+// Server to client:
+//
+//	{"type": "lobbies", "lobbies": [...]}
+//	{"type": "lobby", "lobby": {...}}          (whenever seats or status change)
+//	{"type": "joined", "seat": 2, "token": "..."}  (keep the token to reconnect)
+//	{"type": "event", "event": {...}}          (each public step of the game)
+//	{"type": "state", "view": {...}}           (the client's view once the game settles)
+//	{"type": "error", "error": "..."}
+//
+// Present choices are committed simultaneously: every human seat is offered
+// the decision at once and the cards are revealed together. Spectators get
+// the public view, which has no hands or decisions.
+//
+// A player who drops out of a game has lobbyGrace to reconnect before the
+// AI that filled the empty seats takes theirs over. Lobbies nobody is in are
+// closed once their game is over, or after lobbyGrace if it is not.
+
+const (
+	lobbySendBuffer = 256             // Messages queued per client before it is dropped
+	lobbyGrace      = 2 * time.Minute // How long a lost seat or an empty lobby is kept
+)
+
+// LobbyServer hosts many lobbies and their games in one process
+type LobbyServer struct {
+	mux *http.ServeMux
+
+	grace time.Duration
+
+	mu      sync.Mutex
+	lobbies map[string]*Lobby
+	nextID  int
+}
+
+// Lobby gathers players for one game and then hosts it
+type Lobby struct {
+	ID      string
+	Name    string
+	Players int
+	Rules   RuleConfig
+
+	mu      sync.Mutex
+	seats   []lobbySeat
+	clients map[*lobbyClient]bool
+	session *Session     // Set once the game starts
+	fill    string       // Agent spec for the seats the AI plays
+	newFill func() Agent // Makes the agents for those seats
+}
+
+// lobbySeat is one place at the table
+type lobbySeat struct {
+	name   string
+	ai     string       // Agent spec once filled in by the AI
+	token  string       // Secret that lets the player reconnect
+	client *lobbyClient // Nil while disconnected
+	lost   time.Time    // When the player last disconnected
+}
+
+// lobbyClient is one WebSocket connection. Its lobby is only used by the
+// client's own goroutine; its seat is guarded by the lobby's lock.
+type lobbyClient struct {
+	conn  *wsConn
+	lobby *Lobby
+	seat  int // -1 for spectators
+
+	mu     sync.Mutex
+	send   chan []byte
+	closed bool
+}
+
+// LobbyInfo describes a lobby to clients
+type LobbyInfo struct {
+	ID      string     `json:"id"`
+	Name    string     `json:"name"`
+	Players int        `json:"players"`
+	Rules   RuleConfig `json:"rules"`
+	Seats   []SeatInfo `json:"seats"`
+	Started bool       `json:"started"`
+	Winner  int        `json:"winner"` // -1 until the game is over
+}
+
+// SeatInfo describes who holds a seat
+type SeatInfo struct {
+	Name      string `json:"name,omitempty"` // Empty while the seat is free
+	AI        string `json:"ai,omitempty"`
+	Connected bool   `json:"connected"`
+}
+
+type lobbyRequest struct {
+	Type    string      `json:"type"`
+	Lobby   string      `json:"lobby"`
+	Name    string      `json:"name"`
+	Players int         `json:"players"`
+	Rules   *RuleConfig `json:"rules"`
+	Seat    *int        `json:"seat"`
+	Token   string      `json:"token"`
+	Agent   string      `json:"agent"`
+	Move    *Move       `json:"move"`
+}
+
+type lobbyMessage struct {
+	Type    string      `json:"type"`
+	Lobbies []LobbyInfo `json:"lobbies,omitempty"`
+	Lobby   *LobbyInfo  `json:"lobby,omitempty"`
+	Seat    *int        `json:"seat,omitempty"`
+	Token   string      `json:"token,omitempty"`
+	Event   *Event      `json:"event,omitempty"`
+	View    *PlayerView `json:"view,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+// NewLobbyServer creates a server with no lobbies
+func NewLobbyServer() *LobbyServer {
+	s := &LobbyServer{mux: http.NewServeMux(), grace: lobbyGrace, lobbies: make(map[string]*Lobby)}
+	s.mux.HandleFunc("GET /ws", s.serveWebSocket)
+	s.mux.HandleFunc("GET /lobbies", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.list())
+	})
+	return s
+}
+
+func (s *LobbyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// serveWebSocket handles one client until it disconnects
+func (s *LobbyServer) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		return
+	}
+	c := &lobbyClient{conn: conn, send: make(chan []byte, lobbySendBuffer), seat: -1}
+	go c.writeLoop()
+	defer func() {
+		s.detach(c)
+		c.close()
+	}()
+
+	for {
+		data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var req lobbyRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			c.sendError(fmt.Errorf("invalid message: %v", err))
+			continue
+		}
+		if err := s.handle(c, req); err != nil {
+			c.sendError(err)
+		}
+	}
+}
+
+// handle carries out one request from a client
+func (s *LobbyServer) handle(c *lobbyClient, req lobbyRequest) error {
+	switch req.Type {
+	case "list":
+		c.sendMessage(lobbyMessage{Type: "lobbies", Lobbies: s.list()})
+	case "create":
+		lobby, err := s.create(req)
+		if err != nil {
+			return err
+		}
+		info := lobby.info()
+		c.sendMessage(lobbyMessage{Type: "lobby", Lobby: &info})
+	case "join":
+		return s.join(c, req)
+	case "spectate":
+		return s.spectate(c, req)
+	case "reconnect":
+		return s.reconnect(c, req)
+	case "start":
+		return s.start(c, req)
+	case "move":
+		return s.move(c, req)
+	case "leave":
+		s.detach(c)
+	default:
+		return fmt.Errorf("unknown message type %q", req.Type)
+	}
+	return nil
+}
+
+// list describes every lobby
+func (s *LobbyServer) list() []LobbyInfo {
+	s.mu.Lock()
+	lobbies := make([]*Lobby, 0, len(s.lobbies))
+	for _, lobby := range s.lobbies {
+		lobbies = append(lobbies, lobby)
+	}
+	s.mu.Unlock()
+
+	infos := make([]LobbyInfo, len(lobbies))
+	for i, lobby := range lobbies {
+		infos[i] = lobby.info()
+	}
+	return infos
+}
+
+// create opens a new lobby with every seat free
+func (s *LobbyServer) create(req lobbyRequest) (*Lobby, error) {
+	if req.Players < 2 || req.Players > 5 {
+		return nil, fmt.Errorf("players must be between 2 and 5")
+	}
+	rules := DefaultRules()
+	if req.Rules != nil {
+		rules = *req.Rules
+	}
+	if err := validateClientRules(rules, req.Players); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	lobby := &Lobby{
+		ID:      strconv.Itoa(s.nextID),
+		Name:    req.Name,
+		Players: req.Players,
+		Rules:   rules,
+		seats:   make([]lobbySeat, req.Players),
+		clients: make(map[*lobbyClient]bool),
+	}
+	if lobby.Name == "" {
+		lobby.Name = "Game " + lobby.ID
+	}
+	s.lobbies[lobby.ID] = lobby
+	time.AfterFunc(s.grace, func() { s.expire(lobby) })
+	return lobby, nil
+}
+
+// find looks up a lobby by ID
+func (s *LobbyServer) find(id string) (*Lobby, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lobby := s.lobbies[id]
+	if lobby == nil {
+		return nil, fmt.Errorf("no lobby %q", id)
+	}
+	return lobby, nil
+}
+
+// join claims a free seat in a lobby that has not started
+func (s *LobbyServer) join(c *lobbyClient, req lobbyRequest) error {
+	if c.lobby != nil {
+		return fmt.Errorf("leave your current lobby first")
+	}
+	if req.Name == "" {
+		return fmt.Errorf("a name is needed to join")
+	}
+	lobby, err := s.find(req.Lobby)
+	if err != nil {
+		return err
+	}
+
+	lobby.mu.Lock()
+	if lobby.session != nil {
+		lobby.mu.Unlock()
+		return fmt.Errorf("the game has started; spectate or reconnect instead")
+	}
+	seat := -1
+	if req.Seat != nil {
+		if *req.Seat < 0 || *req.Seat >= lobby.Players || lobby.seats[*req.Seat].name != "" {
+			lobby.mu.Unlock()
+			return fmt.Errorf("seat %d is not free", *req.Seat)
+		}
+		seat = *req.Seat
+	} else {
+		for i, st := range lobby.seats {
+			if st.name == "" {
+				seat = i
+				break
+			}
+		}
+		if seat == -1 {
+			lobby.mu.Unlock()
+			return fmt.Errorf("the lobby is full")
+		}
+	}
+	token := newToken()
+	lobby.seats[seat] = lobbySeat{name: req.Name, token: token, client: c}
+	lobby.clients[c] = true
+	c.lobby, c.seat = lobby, seat
+	lobby.mu.Unlock()
+
+	c.sendMessage(lobbyMessage{Type: "joined", Seat: &seat, Token: token})
+	lobby.broadcastInfo()
+	return nil
+}
+
+// spectate watches a lobby without a seat
+func (s *LobbyServer) spectate(c *lobbyClient, req lobbyRequest) error {
+	if c.lobby != nil {
+		return fmt.Errorf("leave your current lobby first")
+	}
+	lobby, err := s.find(req.Lobby)
+	if err != nil {
+		return err
+	}
+	lobby.mu.Lock()
+	lobby.clients[c] = true
+	c.lobby, c.seat = lobby, -1
+	session := lobby.session
+	lobby.mu.Unlock()
+
+	info := lobby.info()
+	c.sendMessage(lobbyMessage{Type: "lobby", Lobby: &info})
+	if session != nil {
+		view := session.View(-1)
+		c.sendMessage(lobbyMessage{Type: "state", View: &view})
+	}
+	return nil
+}
+
+// reconnect takes back a seat with its token, replacing any older connection
+func (s *LobbyServer) reconnect(c *lobbyClient, req lobbyRequest) error {
+	if c.lobby != nil {
+		return fmt.Errorf("leave your current lobby first")
+	}
+	lobby, err := s.find(req.Lobby)
+	if err != nil {
+		return err
+	}
+
+	lobby.mu.Lock()
+	seat := -1
+	for i, st := range lobby.seats {
+		if st.token != "" && st.token == req.Token {
+			seat = i
+		}
+	}
+	if seat == -1 {
+		lobby.mu.Unlock()
+		return fmt.Errorf("unknown token")
+	}
+	if old := lobby.seats[seat].client; old != nil {
+		delete(lobby.clients, old)
+		old.conn.Close()
+	}
+	lobby.seats[seat].client = c
+	lobby.clients[c] = true
+	c.lobby, c.seat = lobby, seat
+	session := lobby.session
+	lobby.mu.Unlock()
+
+	c.sendMessage(lobbyMessage{Type: "joined", Seat: &seat, Token: req.Token})
+	lobby.broadcastInfo()
+	if session != nil {
+		view := session.View(seat)
+		c.sendMessage(lobbyMessage{Type: "state", View: &view})
+	}
+	return nil
+}
+
+// start fills the empty seats with AI players and begins the game
+func (s *LobbyServer) start(c *lobbyClient, req lobbyRequest) error {
+	lobby := c.lobby
+	if lobby == nil || c.seat < 0 {
+		return fmt.Errorf("only seated players can start a game")
+	}
+	spec := req.Agent
+	if spec == "" {
+		spec = "greedy"
+	}
+	if !serverAgents[spec] {
+		return fmt.Errorf("unknown agent %q", spec)
+	}
+	newAgent, err := ParseAgent(spec)
+	if err != nil {
+		return err
+	}
+
+	lobby.mu.Lock()
+	if lobby.session != nil {
+		lobby.mu.Unlock()
+		return fmt.Errorf("the game has already started")
+	}
+	agents := make([]Agent, lobby.Players)
+	for i := range lobby.seats {
+		if lobby.seats[i].name == "" {
+			lobby.seats[i].name = fmt.Sprintf("AI %d", i)
+			lobby.seats[i].ai = spec
+			agents[i] = newAgent()
+		}
+	}
+	game := NewSeededGame(lobby.Players, mathrand.Int63(), false)
+	game.Rules = lobby.Rules
+	lobby.fill, lobby.newFill = spec, newAgent
+	lobby.session = NewSession(lobby.ID, game, agents)
+	game.Observers = append(game.Observers, lobby)
+	session := lobby.session
+	lobby.mu.Unlock()
+
+	lobby.broadcastInfo()
+	session.Start()
+	lobby.broadcastStates()
+	return nil
+}
+
+// move submits a move for the client's seat
+func (s *LobbyServer) move(c *lobbyClient, req lobbyRequest) error {
+	lobby := c.lobby
+	if lobby == nil || c.seat < 0 {
+		return fmt.Errorf("only seated players can move")
+	}
+	lobby.mu.Lock()
+	session := lobby.session
+	lobby.mu.Unlock()
+	if session == nil {
+		return fmt.Errorf("the game has not started")
+	}
+	if req.Move == nil {
+		return fmt.Errorf("missing move")
+	}
+	if err := session.Submit(c.seat, *req.Move); err != nil {
+		return err
+	}
+	lobby.broadcastStates()
+	if session.View(-1).Winner != -1 {
+		lobby.broadcastInfo()
+	}
+	return nil
+}
+
+// detach removes a client from its lobby. Before the game starts the seat
+// is freed; afterwards it is kept for the player to reconnect until the
+// grace period runs out. Lobbies with nobody left are closed at once if
+// their game is over or was never started, and otherwise when it runs out.
+func (s *LobbyServer) detach(c *lobbyClient) {
+	lobby := c.lobby
+	if lobby == nil {
+		return
+	}
+
+	lobby.mu.Lock()
+	if lobby.clients[c] {
+		delete(lobby.clients, c)
+		if c.seat >= 0 && lobby.seats[c.seat].client == c {
+			if lobby.session == nil {
+				lobby.seats[c.seat] = lobbySeat{}
+			} else {
+				lobby.seats[c.seat].client = nil
+				lobby.seats[c.seat].lost = time.Now()
+			}
+		}
+	}
+	c.lobby, c.seat = nil, -1
+	empty := len(lobby.clients) == 0
+	session := lobby.session
+	lobby.mu.Unlock()
+
+	if session != nil && session.View(-1).Winner == -1 {
+		time.AfterFunc(s.grace, func() { s.expire(lobby) })
+	} else if empty {
+		s.remove(lobby)
+		return
+	}
+	lobby.broadcastInfo()
+}
+
+// expire hands the seats of players who have been gone for the grace period
+// to the AI, then closes the lobby if nobody is left in it
+func (s *LobbyServer) expire(lobby *Lobby) {
+	lobby.mu.Lock()
+	session, newAgent := lobby.session, lobby.newFill
+	var lost []int
+	if session != nil {
+		for i, st := range lobby.seats {
+			if st.ai == "" && st.client == nil && time.Since(st.lost) >= s.grace {
+				lobby.seats[i].ai = lobby.fill
+				lobby.seats[i].token = ""
+				lost = append(lost, i)
+			}
+		}
+	}
+	empty := len(lobby.clients) == 0
+	lobby.mu.Unlock()
+
+	for _, seat := range lost {
+		session.Substitute(seat, newAgent())
+	}
+	if empty {
+		if session != nil {
+			session.Close()
+		}
+		s.remove(lobby)
+		return
+	}
+	if len(lost) > 0 {
+		lobby.broadcastInfo()
+		lobby.broadcastStates()
+	}
+}
+
+// remove forgets a lobby
+func (s *LobbyServer) remove(lobby *Lobby) {
+	s.mu.Lock()
+	delete(s.lobbies, lobby.ID)
+	s.mu.Unlock()
+}
+
+// info describes the lobby
+func (l *Lobby) info() LobbyInfo {
+	l.mu.Lock()
+	info := LobbyInfo{
+		ID:      l.ID,
+		Name:    l.Name,
+		Players: l.Players,
+		Rules:   l.Rules,
+		Started: l.session != nil,
+		Winner:  -1,
+	}
+	for _, st := range l.seats {
+		info.Seats = append(info.Seats, SeatInfo{Name: st.name, AI: st.ai, Connected: st.client != nil})
+	}
+	session := l.session
+	l.mu.Unlock()
+
+	if session != nil {
+		info.Winner = session.View(-1).Winner
+	}
+	return info
+}
+
+// seatedClient is a client and the seat it held when the lobby was copied
+type seatedClient struct {
+	client *lobbyClient
+	seat   int
+}
+
+// snapshot copies the lobby's clients
+func (l *Lobby) snapshot() []seatedClient {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	clients := make([]seatedClient, 0, len(l.clients))
+	for c := range l.clients {
+		clients = append(clients, seatedClient{c, c.seat})
+	}
+	return clients
+}
+
+// broadcastInfo sends the lobby description to everyone in it
+func (l *Lobby) broadcastInfo() {
+	info := l.info()
+	for _, sc := range l.snapshot() {
+		sc.client.sendMessage(lobbyMessage{Type: "lobby", Lobby: &info})
+	}
+}
+
+// broadcastStates sends each client its own view of the game
+func (l *Lobby) broadcastStates() {
+	l.mu.Lock()
+	session := l.session
+	l.mu.Unlock()
+	for _, sc := range l.snapshot() {
+		view := session.View(sc.seat)
+		sc.client.sendMessage(lobbyMessage{Type: "state", View: &view})
+	}
+}
+
+// Observe streams the game's public events to everyone in the lobby. It is
+// called by the engine, so it only queues messages.
+func (l *Lobby) Observe(g *Game, e Event) {
+	data, err := json.Marshal(lobbyMessage{Type: "event", Event: &e})
+	if err != nil {
+		return
+	}
+	for _, sc := range l.snapshot() {
+		sc.client.queue(data)
+	}
+}
+
+// sendMessage queues a message for the client
+func (c *lobbyClient) sendMessage(m lobbyMessage) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return
+	}
+	c.queue(data)
+}
+
+// sendError reports a failed request to the client
+func (c *lobbyClient) sendError(err error) {
+	c.sendMessage(lobbyMessage{Type: "error", Error: err.Error()})
+}
+
+// queue hands data to the writer, dropping clients that fall too far behind
+func (c *lobbyClient) queue(data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	select {
+	case c.send <- data:
+	default:
+		c.conn.Close()
+	}
+}
+
+// close stops the writer once it has sent what is queued
+func (c *lobbyClient) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.closed = true
+		close(c.send)
+	}
+}
+
+// writeLoop sends queued messages until the client goes away
+func (c *lobbyClient) writeLoop() {
+	for data := range c.send {
+		if err := c.conn.WriteMessage(data); err != nil {
+			c.conn.Close()
+		}
+	}
+	c.conn.Close()
+}
+
+// newToken returns a random secret for reconnecting to a seat
+func newToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// RunLobbyServer serves lobbies until the process is stopped
+func RunLobbyServer(addr string) {
+	fmt.Printf("Serving lobbies on ws://%s/ws\n", addr)
+	if err := http.ListenAndServe(addr, NewLobbyServer()); err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+}
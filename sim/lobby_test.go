@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// lobbyReply is the part of a server message the tests read back. Of an
+// event only the kind is kept, as cards are only ever written as JSON.
+type lobbyReply struct {
+	Type    string      `json:"type"`
+	Lobbies []LobbyInfo `json:"lobbies"`
+	Lobby   *LobbyInfo  `json:"lobby"`
+	Seat    *int        `json:"seat"`
+	Token   string      `json:"token"`
+	Event   *struct {
+		Kind EventKind `json:"kind"`
+	} `json:"event"`
+	View  *apiView `json:"view"`
+	Error string   `json:"error"`
+}
+
+// wsClient is the client end of a lobby connection, framing messages by
+// hand as a browser would
+type wsClient struct {
+	t       *testing.T
+	conn    net.Conn
+	replies chan lobbyReply // Closed when the server closes the connection
+}
+
+// dialLobby opens a WebSocket to the server's /ws, checking the handshake
+func dialLobby(t *testing.T, server *httptest.Server) *wsClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	key := base64.StdEncoding.EncodeToString(nonce)
+	fmt.Fprintf(conn, "GET /ws HTTP/1.1\r\nHost: test\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: %s\r\nSec-WebSocket-Version: 13\r\n\r\n", key)
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha1.Sum([]byte(key + wsGUID))
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(sum[:]) {
+		t.Fatalf("bad handshake: %s %v", resp.Status, resp.Header)
+	}
+
+	c := &wsClient{t: t, conn: conn, replies: make(chan lobbyReply, 4096)}
+	go c.readLoop(br)
+	t.Cleanup(func() { conn.Close() })
+	return c
+}
+
+// readLoop decodes the server's frames, which are never masked or
+// fragmented, into replies
+func (c *wsClient) readLoop(br *bufio.Reader) {
+	defer close(c.replies)
+	for {
+		var header [2]byte
+		if _, err := io.ReadFull(br, header[:]); err != nil {
+			return
+		}
+		length := uint64(header[1] & 0x7F)
+		switch length {
+		case 126:
+			var ext [2]byte
+			io.ReadFull(br, ext[:])
+			length = uint64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			io.ReadFull(br, ext[:])
+			length = binary.BigEndian.Uint64(ext[:])
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(br, payload); err != nil {
+			return
+		}
+		switch opcode := header[0] & 0x0F; opcode {
+		case wsText:
+			var reply lobbyReply
+			if err := json.Unmarshal(payload, &reply); err != nil {
+				c.t.Errorf("undecodable message %s: %v", payload, err)
+				return
+			}
+			c.replies <- reply
+		case wsPong:
+			c.replies <- lobbyReply{Type: "pong", Error: string(payload)}
+		case wsClose:
+			return
+		}
+	}
+}
+
+// frame sends one masked frame, as every client frame must be
+func (c *wsClient) frame(fin bool, opcode byte, payload []byte) {
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+	header := []byte{first}
+	if len(payload) < 126 {
+		header = append(header, 0x80|byte(len(payload)))
+	} else {
+		header = append(header, 0x80|126)
+		header = binary.BigEndian.AppendUint16(header, uint16(len(payload)))
+	}
+	mask := make([]byte, 4)
+	rand.Read(mask)
+	masked := make([]byte, len(payload))
+	for i := range payload {
+		masked[i] = payload[i] ^ mask[i%4]
+	}
+	if _, err := c.conn.Write(slices.Concat(header, mask, masked)); err != nil {
+		c.t.Error(err)
+	}
+}
+
+// send writes a request as one text message
+func (c *wsClient) send(req lobbyRequest) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	c.frame(true, wsText, data)
+}
+
+// expect waits for the next message of the given type, skipping others
+func (c *wsClient) expect(kind string) lobbyReply {
+	c.t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case reply, ok := <-c.replies:
+			if !ok {
+				c.t.Fatalf("connection closed while waiting for %s", kind)
+			}
+			if reply.Type == kind {
+				return reply
+			}
+		case <-timeout:
+			c.t.Fatalf("no %s message within 5s", kind)
+		}
+	}
+}
+
+// latestState waits for a state and returns the newest one queued
+func (c *wsClient) latestState() apiView {
+	c.t.Helper()
+	view := *c.expect("state").View
+	for {
+		select {
+		case reply, ok := <-c.replies:
+			if ok && reply.Type == "state" {
+				view = *reply.View
+			}
+		default:
+			return view
+		}
+	}
+}
+
+// lobbyFor creates a lobby for two players and seats a client in each seat
+func lobbyFor(t *testing.T, server *httptest.Server) (lobby string, players [2]*wsClient, tokens [2]string) {
+	t.Helper()
+	players[0], players[1] = dialLobby(t, server), dialLobby(t, server)
+	players[0].send(lobbyRequest{Type: "create", Name: "Test", Players: 2})
+	lobby = players[0].expect("lobby").Lobby.ID
+	for seat, c := range players {
+		c.send(lobbyRequest{Type: "join", Lobby: lobby, Seat: &seat, Name: fmt.Sprintf("P%d", seat)})
+		joined := c.expect("joined")
+		if joined.Seat == nil || *joined.Seat != seat || joined.Token == "" {
+			t.Fatalf("join seat %d: got %+v", seat, joined)
+		}
+		tokens[seat] = joined.Token
+	}
+	return lobby, players, tokens
+}
+
+// playToPresent makes the first legal move for either seat until the
+// Present decision opens, and returns the state in which seat 0 sees it
+func playToPresent(t *testing.T, players [2]*wsClient) apiView {
+	t.Helper()
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); {
+		for _, c := range players {
+			select {
+			case reply := <-c.replies:
+				if reply.Type != "state" || reply.View.Decision == nil {
+					continue
+				}
+				if reply.View.Decision.Kind == "present" {
+					if c == players[0] {
+						return *reply.View
+					}
+					continue
+				}
+				c.send(lobbyRequest{Type: "move", Move: &reply.View.Decision.Moves[0]})
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}
+	t.Fatal("the Present decision never opened")
+	return apiView{}
+}
+
+func TestLobbyFraming(t *testing.T) {
+	server := httptest.NewServer(NewLobbyServer())
+	defer server.Close()
+	c := dialLobby(t, server)
+
+	// A request split over a text frame and a continuation, with a ping
+	// between them as RFC 6455 allows
+	c.frame(false, wsText, []byte(`{"type":`))
+	c.frame(true, wsPing, []byte("hi"))
+	c.frame(true, wsContinuation, []byte(`"list"}`))
+	if pong := c.expect("pong"); pong.Error != "hi" {
+		t.Errorf("pong carried %q, want the ping's payload", pong.Error)
+	}
+	c.expect("lobbies")
+
+	c.send(lobbyRequest{Type: "nonsense"})
+	if reply := c.expect("error"); !strings.Contains(reply.Error, "nonsense") {
+		t.Errorf("unexpected error %q", reply.Error)
+	}
+}
+
+func TestLobbyPlay(t *testing.T) {
+	server := httptest.NewServer(NewLobbyServer())
+	defer server.Close()
+	lobby, players, tokens := lobbyFor(t, server)
+
+	spectator := dialLobby(t, server)
+	spectator.send(lobbyRequest{Type: "spectate", Lobby: lobby})
+	spectator.expect("lobby")
+
+	players[0].send(lobbyRequest{Type: "start"})
+
+	// Every seat is offered the Present decision at once, and committing a
+	// card does not settle the trick for the seat still choosing
+	offered := playToPresent(t, players)
+	if !slices.Equal(offered.WaitingFor, []int{0, 1}) {
+		t.Fatalf("Present was offered to %v, want both seats at once", offered.WaitingFor)
+	}
+	players[0].send(lobbyRequest{Type: "move", Move: &offered.Decision.Moves[0]})
+	committed := players[0].latestState()
+	if committed.Decision != nil || !slices.Equal(committed.WaitingFor, []int{1}) {
+		t.Fatalf("after seat 0 commits: decision %+v, waiting for %v", committed.Decision, committed.WaitingFor)
+	}
+
+	// Spectators see the public view: no hands and no decisions
+	watched := spectator.latestState()
+	if watched.Hand != nil || watched.Decision != nil {
+		t.Errorf("spectator sees hand %v and decision %+v", watched.Hand, watched.Decision)
+	}
+
+	// A dropped player takes their seat back with the token, hand and all
+	players[1].conn.Close()
+	back := dialLobby(t, server)
+	back.send(lobbyRequest{Type: "reconnect", Lobby: lobby, Token: tokens[1]})
+	if joined := back.expect("joined"); joined.Seat == nil || *joined.Seat != 1 {
+		t.Fatalf("reconnect gave %+v", joined)
+	}
+	resumed := back.latestState()
+	if len(resumed.Hand) == 0 || resumed.Decision == nil || resumed.Decision.Kind != "present" {
+		t.Fatalf("reconnected seat sees hand %v and decision %+v", resumed.Hand, resumed.Decision)
+	}
+	back.send(lobbyRequest{Type: "move", Move: &resumed.Decision.Moves[0]})
+	for done := false; !done; {
+		done = players[0].expect("event").Event.Kind == EventTrickWon
+	}
+
+	back.send(lobbyRequest{Type: "reconnect", Lobby: lobby, Token: "wrong"})
+	back.expect("error")
+}
+
+func TestLobbyHandsLostSeatToAI(t *testing.T) {
+	lobbies := NewLobbyServer()
+	lobbies.grace = 100 * time.Millisecond
+	server := httptest.NewServer(lobbies)
+	defer server.Close()
+	lobby, players, _ := lobbyFor(t, server)
+
+	players[0].send(lobbyRequest{Type: "start"})
+	playToPresent(t, players)
+	players[1].conn.Close()
+
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		info := players[0].expect("lobby").Lobby
+		if seat := info.Seats[1]; seat.AI != "" && !seat.Connected {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("seat 1 was not handed to the AI: %+v", info.Seats)
+		}
+	}
+
+	// The game goes on without waiting for the lost seat
+	view := players[0].latestState()
+	if slices.Contains(view.WaitingFor, 1) {
+		t.Fatalf("the game still waits on the lost seat: %v", view.WaitingFor)
+	}
+
+	// Once everyone has gone, the lobby is closed
+	players[0].conn.Close()
+	observer := dialLobby(t, server)
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(20 * time.Millisecond) {
+		observer.send(lobbyRequest{Type: "list"})
+		listed := observer.expect("lobbies").Lobbies
+		if !slices.ContainsFunc(listed, func(l LobbyInfo) bool { return l.ID == lobby }) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("lobby %s was kept with nobody in it", lobby)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"net/http"
+	"os"
+	"sort"
+)
+
+// AIFacet is a token on the board as the web app holds it
+type AIFacet struct {
+	AttrIndex    int `json:"attrIndex"`
+	DesiredValue int `json:"desiredValue"` // An index into the attribute's values, 0 or 1 for two-sided tiles
+}
+
+// AIAction is a manipulation as the web app holds it: {"type":"flip","slot":s}
+// or {"type":"swap","slotA":a,"slotB":b}, with 0-based slots
+type AIAction struct {
+	Type  string `json:"type"`
+	Slot  int    `json:"slot"`
+	SlotA int    `json:"slotA"`
+	SlotB int    `json:"slotB"`
+}
+
+// webActionKinds are the manipulations the web app can apply. It holds no
+// locks or peeked cards, so requests whose rules allow any other kind are
+// refused rather than answered with actions it cannot play.
+var webActionKinds = map[string]bool{"flip": true, "swap": true}
+
+// MarshalJSON writes only the slots the action's type uses
+func (a AIAction) MarshalJSON() ([]byte, error) {
+	if a.Type == "swap" {
+		return json.Marshal(map[string]any{"type": a.Type, "slotA": a.SlotA, "slotB": a.SlotB})
+	}
+	return json.Marshal(map[string]any{"type": a.Type, "slot": a.Slot})
+}
+
+// AIRequest is the body of POST /ai/decide. It describes the table from one
+// seat's point of view, in the web app's terms: cards are their attribute
+// values in attribute order (six 0/1 values in the printed edition) and
+// slots are 0-based.
+type AIRequest struct {
+	Agent       string      `json:"agent"` // A server agent; default "hard"
+	Kind        string      `json:"kind"`  // "draft", "present" or "manipulate"
+	Seat        int         `json:"seat"`
+	Leader      int         `json:"leader"`     // Seat holding the Queen's Favor
+	Scores      []int       `json:"scores"`     // Tricks won by each seat
+	HandSizes   []int       `json:"hand_sizes"` // Cards left in each hand; default the size of this seat's hand
+	Trick       int         `json:"trick"`      // 1-based trick within the hand
+	SuddenDeath bool        `json:"sudden_death"`
+	Hand        [][]int     `json:"hand"`
+	Revealed    [][]int     `json:"revealed"`  // Cards presented so far this hand
+	Board       []*AIFacet  `json:"board"`     // Slot 1 first; null for empty slots
+	Available   []int       `json:"available"` // draft: attributes not yet on the board
+	Slot        int         `json:"slot"`      // draft: the slot being filled
+	Forbidden   *AIAction   `json:"forbidden"` // manipulate: the previous player's action
+	Rules       *RuleConfig `json:"rules"`
+}
+
+// AIResponse carries the decision; only the field for the request's kind is set
+type AIResponse struct {
+	Facet  *AIFacet  `json:"facet,omitempty"`  // draft
+	Card   *int      `json:"card,omitempty"`   // present: index into the hand
+	Action *AIAction `json:"action,omitempty"` // manipulate
+}
+
+// decodeCard converts a web app card to a Card of the edition
+func decodeCard(values []int, edition *Edition) (Card, error) {
+	if len(values) != edition.NumAttributes() {
+		return Card{}, fmt.Errorf("cards must have %d attribute values, got %d", edition.NumAttributes(), len(values))
+	}
+	card := Card{Edition: edition}
+	for i, value := range values {
+		if value < 0 || value >= edition.NumValues(Attribute(i)) {
+			return Card{}, fmt.Errorf("%s has no value %d", edition.AttributeName(Attribute(i)), value)
+		}
+		card.Attributes[i] = value
+	}
+	return card, nil
+}
+
+// toAction converts a web app action on a board of numSlots slots to an Action
+func (a AIAction) toAction(numSlots int) (Action, error) {
+	switch a.Type {
+	case "flip":
+		if a.Slot < 0 || a.Slot >= numSlots {
+			return Action{}, fmt.Errorf("no slot %d", a.Slot)
+		}
+		return Action{Type: "flip", SlotIndex: a.Slot}, nil
+	case "swap":
+		if a.SlotA < 0 || a.SlotA >= numSlots || a.SlotB < 0 || a.SlotB >= numSlots || a.SlotA == a.SlotB {
+			return Action{}, fmt.Errorf("cannot swap slots %d and %d", a.SlotA, a.SlotB)
+		}
+		return Action{Type: "swap", SlotIndex: a.SlotA, SlotIndex2: a.SlotB}, nil
+	}
+	return Action{}, fmt.Errorf("unknown action %q", a.Type)
+}
+
+// webAction converts an Action to the web app's form
+func webAction(a Action) *AIAction {
+	if a.Type == "swap" {
+		return &AIAction{Type: "swap", SlotA: a.SlotIndex, SlotB: a.SlotIndex2}
+	}
+	return &AIAction{Type: a.Type, Slot: a.SlotIndex}
+}
+
+// game rebuilds the table the request describes. Cards the seat cannot see
+// are dealt to the other hands at random from those still unaccounted for.
+func (req AIRequest) game() (*Game, error) {
+	numPlayers := len(req.Scores)
+	if numPlayers < 2 || numPlayers > 5 {
+		return nil, fmt.Errorf("scores must list 2 to 5 players")
+	}
+	if req.Seat < 0 || req.Seat >= numPlayers || req.Leader < 0 || req.Leader >= numPlayers {
+		return nil, fmt.Errorf("seat and leader must be between 0 and %d", numPlayers-1)
+	}
+	if req.HandSizes != nil && len(req.HandSizes) != numPlayers {
+		return nil, fmt.Errorf("expected %d hand sizes, got %d", numPlayers, len(req.HandSizes))
+	}
+
+	g := NewSeededGame(numPlayers, rand.Int63(), false)
+	if req.Rules != nil {
+		g.Rules = *req.Rules
+	}
+	if err := g.Rules.Validate(numPlayers); err != nil {
+		return nil, err
+	}
+	for _, name := range g.Rules.ActionNames() {
+		if !webActionKinds[name] {
+			return nil, fmt.Errorf("the web app cannot play %s actions", name)
+		}
+	}
+	edition := g.Edition()
+	if len(req.Board) != edition.NumAttributes() {
+		return nil, fmt.Errorf("board must have %d slots", edition.NumAttributes())
+	}
+	g.Deck = g.NewDeck()
+	g.Board = newBoard(edition.NumAttributes())
+	g.CurrentLeader = req.Leader
+	g.TrickNumber = max(req.Trick, 1)
+	g.SuddenDeath = req.SuddenDeath
+	for i, score := range req.Scores {
+		g.Players[i].TricksWon = score
+		g.Players[i].Score = score
+	}
+
+	placed := make(map[int]bool)
+	for i, facet := range req.Board {
+		if facet == nil {
+			continue
+		}
+		if facet.AttrIndex < 0 || facet.AttrIndex >= edition.NumAttributes() || placed[facet.AttrIndex] || facet.DesiredValue < 0 || facet.DesiredValue >= edition.NumValues(Attribute(facet.AttrIndex)) {
+			return nil, fmt.Errorf("invalid facet in slot %d", i)
+		}
+		placed[facet.AttrIndex] = true
+		g.Board.Slots[i] = &AttributeToken{Attribute: Attribute(facet.AttrIndex), Value: facet.DesiredValue, Edition: edition}
+	}
+
+	seen := make(map[Card]int)
+	player := g.Players[req.Seat]
+	for _, bits := range req.Hand {
+		card, err := decodeCard(bits, edition)
+		if err != nil {
+			return nil, err
+		}
+		if seen[card] == g.Rules.DeckCount() {
+			return nil, fmt.Errorf("card %v appears more often than the decks hold it", card)
+		}
+		seen[card]++
+		player.Hand = append(player.Hand, card)
+	}
+	for _, bits := range req.Revealed {
+		card, err := decodeCard(bits, edition)
+		if err != nil {
+			return nil, err
+		}
+		if seen[card] == g.Rules.DeckCount() {
+			return nil, fmt.Errorf("card %v appears more often than the decks hold it", card)
+		}
+		seen[card]++
+		g.Revealed = append(g.Revealed, card)
+	}
+
+	unseen := []Card{}
+	for _, card := range g.Deck {
+		if seen[card] > 0 {
+			seen[card]--
+		} else {
+			unseen = append(unseen, card)
+		}
+	}
+	ShuffleDeck(g.rng, unseen)
+	for i, p := range g.Players {
+		if i == req.Seat {
+			continue
+		}
+		size := len(player.Hand)
+		if req.HandSizes != nil {
+			size = req.HandSizes[i]
+		}
+		size = min(max(size, 0), len(unseen))
+		p.Hand, unseen = unseen[:size], unseen[size:]
+	}
+	g.Box = unseen
+	return g, nil
+}
+
+// Decide asks the named agent for the decision the request describes
+func (req AIRequest) Decide() (AIResponse, error) {
+	if req.Agent == "" {
+		req.Agent = "hard"
+	}
+	if !serverAgents[req.Agent] {
+		return AIResponse{}, fmt.Errorf("unknown agent %q", req.Agent)
+	}
+	newAgent, err := ParseAgent(req.Agent)
+	if err != nil {
+		return AIResponse{}, err
+	}
+	g, err := req.game()
+	if err != nil {
+		return AIResponse{}, err
+	}
+	agent := newAgent()
+	player := g.Players[req.Seat]
+
+	switch req.Kind {
+	case "draft":
+		if req.Slot < 0 || req.Slot >= len(g.Board.Slots) || g.Board.Slots[req.Slot] != nil {
+			return AIResponse{}, fmt.Errorf("slot %d cannot be drafted", req.Slot)
+		}
+		available := []Attribute{}
+		for _, attr := range req.Available {
+			if attr < 0 || attr >= len(g.Board.Slots) {
+				return AIResponse{}, fmt.Errorf("no attribute %d", attr)
+			}
+			available = append(available, Attribute(attr))
+		}
+		if len(available) == 0 {
+			return AIResponse{}, fmt.Errorf("no attributes available to draft")
+		}
+		sort.Slice(available, func(i, j int) bool { return available[i] < available[j] })
+		attr, value := agent.ChooseToken(g, player, available, req.Slot)
+		return AIResponse{Facet: &AIFacet{AttrIndex: int(attr), DesiredValue: value}}, nil
+
+	case "present":
+		if len(player.Hand) == 0 {
+			return AIResponse{}, fmt.Errorf("the hand is empty")
+		}
+		card := agent.ChooseCard(g, player)
+		return AIResponse{Card: &card}, nil
+
+	case "manipulate":
+		for i, token := range g.Board.Slots {
+			if token == nil {
+				return AIResponse{}, fmt.Errorf("slot %d is empty", i)
+			}
+		}
+		var previous *Action
+		if req.Forbidden != nil {
+			action, err := req.Forbidden.toAction(len(g.Board.Slots))
+			if err != nil {
+				return AIResponse{}, err
+			}
+			previous = &action
+		}
+		return AIResponse{Action: webAction(agent.ChooseAction(g, player, previous))}, nil
+	}
+	return AIResponse{}, fmt.Errorf("unknown decision %q", req.Kind)
+}
+
+// NewLocalServer serves everything needed to play without a network: the
+// web app from assets, the Go AI it can call under /ai/, the HTTP API under
+// /api/, and LAN lobbies at /ws.
+func NewLocalServer(assets fs.FS) http.Handler {
+	mux := http.NewServeMux()
+	files := http.FileServerFS(assets)
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFileFS(w, r, assets, webPage)
+	})
+	mux.Handle("/", files)
+
+	mux.HandleFunc("GET /ai/agents", func(w http.ResponseWriter, r *http.Request) {
+		agents := []string{}
+		for spec := range serverAgents {
+			agents = append(agents, spec)
+		}
+		sort.Strings(agents)
+		writeJSON(w, http.StatusOK, agents)
+	})
+	mux.HandleFunc("POST /ai/decide", func(w http.ResponseWriter, r *http.Request) {
+		var req AIRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %v", err))
+			return
+		}
+		if numPlayers := len(req.Scores); req.Rules != nil && numPlayers >= 2 && numPlayers <= 5 {
+			if err := validateClientRules(*req.Rules, numPlayers); err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+		resp, err := req.Decide()
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	})
+
+	mux.Handle("/api/", http.StripPrefix("/api", NewAPIServer()))
+	lobbies := NewLobbyServer()
+	mux.Handle("GET /ws", lobbies)
+	mux.Handle("GET /lobbies", lobbies)
+	return mux
+}
+
+// RunLocalServer serves the web app and its Go backends until the process
+// is stopped. An empty dir serves the embedded copy of the web app, falling
+// back to ../web when the binary was built without one.
+func RunLocalServer(addr string, dir string) {
+	assets, err := webAssets(dir)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Serving the game on http://%s/\n", addr)
+	if err := http.ListenAndServe(addr, NewLocalServer(assets)); err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+}
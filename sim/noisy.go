@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Difficulty describes how far a NoisyAgent strays from its base agent
+type Difficulty struct {
+	Temperature float64
+	Epsilon     float64
+}
+
+// difficulties are the presets offered to humans playing against the AI.
+// Mistakes compound over a game, so small values already matter: against
+// greedy opponents easy wins about a third of its fair share and medium
+// about three quarters.
+var difficulties = map[string]Difficulty{
+	"easy":   {Temperature: 0.05, Epsilon: 0.05},
+	"medium": {Temperature: 0.02, Epsilon: 0.01},
+	"hard":   {Temperature: 0, Epsilon: 0},
+}
+
+// NoisyAgent turns any agent into a bounded-rational one. With probability
+// Epsilon it makes a uniformly random legal move. Otherwise, if the base
+// agent can score its moves, it samples a move by softmax over those scores
+// at the given Temperature; scores are normalised so the best move is 0 and
+// the worst is -1, which keeps temperatures comparable across phases.
+type NoisyAgent struct {
+	Base        Agent
+	Temperature float64
+	Epsilon     float64
+
+	Mistakes int // Moves that differed from what the base agent would play
+	rng      *rand.Rand
+}
+
+// random returns the agent's generator, seeding it from the game's seed and
+// the seat the first time. Drawing the seed from the game's own generator
+// would shift every later deal, so seeded games would deal different cards
+// to noisy agents than to their base agents.
+func (a *NoisyAgent) random(g *Game, player *Player) *rand.Rand {
+	if a.rng == nil {
+		a.rng = rand.New(rand.NewSource(g.seed ^ int64(player.ID+1)<<32))
+	}
+	return a.rng
+}
+
+func (a *NoisyAgent) ChooseToken(g *Game, player *Player, availableTokens []Attribute, slotIdx int) (Attribute, int) {
+	attr, value := a.Base.ChooseToken(g, player, availableTokens, slotIdx)
+	options := tokenOptions(g.Edition(), availableTokens)
+
+	choice := -1
+	if a.random(g, player).Float64() < a.Epsilon {
+		choice = a.rng.Intn(len(options))
+	} else if scorer, ok := a.Base.(MoveScorer); ok && a.Temperature > 0 {
+		choice = a.sample(scorer.ScoreTokens(g, player, availableTokens, slotIdx))
+	}
+
+	if choice == -1 || (options[choice].Attribute == attr && options[choice].Value == value) {
+		return attr, value
+	}
+	a.Mistakes++
+	return options[choice].Attribute, options[choice].Value
+}
+
+func (a *NoisyAgent) ChooseCard(g *Game, player *Player) int {
+	cardIdx := a.Base.ChooseCard(g, player)
+
+	choice := -1
+	if a.random(g, player).Float64() < a.Epsilon {
+		choice = a.rng.Intn(len(player.Hand))
+	} else if scorer, ok := a.Base.(MoveScorer); ok && a.Temperature > 0 {
+		choice = a.sample(scorer.ScoreCards(g, player))
+	}
+
+	if choice == -1 || choice == cardIdx {
+		return cardIdx
+	}
+	a.Mistakes++
+	return choice
+}
+
+func (a *NoisyAgent) ChooseAction(g *Game, player *Player, previousAction *Action) Action {
+	action := a.Base.ChooseAction(g, player, previousAction)
+	actions := g.legalActions(player, previousAction)
+
+	choice := -1
+	if a.random(g, player).Float64() < a.Epsilon {
+		choice = a.rng.Intn(len(actions))
+	} else if scorer, ok := a.Base.(MoveScorer); ok && a.Temperature > 0 {
+		choice = a.sample(scorer.ScoreActions(g, player, actions))
+	}
+
+	if choice == -1 || actionsMatch(actions[choice], action) {
+		return action
+	}
+	a.Mistakes++
+	if g.showReasoning() {
+		fmt.Printf("  (Player %d slips: %s instead of %s)\n", player.ID, actions[choice], action)
+	}
+	return actions[choice]
+}
+
+// EndGame passes the end of the game on to the base agent, which may have
+// an external process to shut down
+func (a *NoisyAgent) EndGame(g *Game, winner int) {
+	if ender, ok := a.Base.(GameEnder); ok {
+		ender.EndGame(g, winner)
+	}
+}
+
+// sample draws an index with probability proportional to exp(score / T)
+// after normalising the scores to the range [-1, 0]
+func (a *NoisyAgent) sample(scores []float64) int {
+	best, worst := scores[0], scores[0]
+	for _, s := range scores {
+		best = math.Max(best, s)
+		worst = math.Min(worst, s)
+	}
+	spread := best - worst
+	if spread == 0 {
+		return a.rng.Intn(len(scores))
+	}
+
+	weights := make([]float64, len(scores))
+	total := 0.0
+	for i, s := range scores {
+		weights[i] = math.Exp((s - best) / spread / a.Temperature)
+		total += weights[i]
+	}
+
+	r := a.rng.Float64() * total
+	for i, w := range weights {
+		r -= w
+		if r < 0 {
+			return i
+		}
+	}
+	return len(scores) - 1
+}
+
+// bestIndex returns the index of the highest score, preferring the first
+func bestIndex(scores []float64) int {
+	best := 0
+	for i, s := range scores {
+		if s > scores[best] {
+			best = i
+		}
+	}
+	return best
+}
+
+// parseNoisyAgent handles "easy", "medium" and "hard", which wrap the greedy
+// agent, and "noisy:<temperature>:<epsilon>:<agent>" for any other agent
+func parseNoisyAgent(name string, arg string) (AgentFactory, error) {
+	if d, ok := difficulties[name]; ok {
+		return noisyFactory(func() Agent { return GreedyAgent{} }, d), nil
+	}
+
+	parts := strings.SplitN(arg, ":", 3)
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("noisy agent needs noisy:<temperature>:<epsilon>:<agent>")
+	}
+	temperature, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil || temperature < 0 {
+		return nil, fmt.Errorf("invalid temperature %q", parts[0])
+	}
+	epsilon, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil || epsilon < 0 || epsilon > 1 {
+		return nil, fmt.Errorf("invalid epsilon %q", parts[1])
+	}
+	base, err := ParseAgent(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	return noisyFactory(base, Difficulty{Temperature: temperature, Epsilon: epsilon}), nil
+}
+
+// noisyFactory wraps each agent the base factory creates
+func noisyFactory(base AgentFactory, d Difficulty) AgentFactory {
+	return func() Agent {
+		return &NoisyAgent{Base: base(), Temperature: d.Temperature, Epsilon: d.Epsilon}
+	}
+}
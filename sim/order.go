@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// Orders of play in the Manipulate phase, set by RuleConfig.ActionOrder
+const (
+	ActionOrderWinner   = "winner"   // The trick winner first, then clockwise (the published rule)
+	ActionOrderTrailing = "trailing" // The player with the lowest score first, then clockwise
+	ActionOrderReverse  = "reverse"  // Counter-clockwise, ending with the trick winner
+	ActionOrderSecret   = "secret"   // Everyone chooses before anything moves; choices are made from the lowest score up
+)
+
+// actionOrderRules lists the orders in the order error messages give them
+var actionOrderRules = []string{ActionOrderWinner, ActionOrderTrailing, ActionOrderReverse, ActionOrderSecret}
+
+// actionOrder lists the players in the order they manipulate, or for secret
+// manipulation the order their choices are made in. Ties in score go
+// to the player nearest the trick winner clockwise, starting with the winner.
+func (g *Game) actionOrder() []int {
+	clockwise := make([]int, g.NumPlayers)
+	for i := range clockwise {
+		clockwise[i] = (g.CurrentLeader + i) % g.NumPlayers
+	}
+
+	switch g.Rules.ActionOrderRule() {
+	case ActionOrderTrailing:
+		first := 0
+		for i, id := range clockwise {
+			if g.Players[id].Score < g.Players[clockwise[first]].Score {
+				first = i
+			}
+		}
+		return append(clockwise[first:], clockwise[:first]...)
+	case ActionOrderReverse:
+		slices.Reverse(clockwise)
+	case ActionOrderSecret:
+		sort.SliceStable(clockwise, func(i, j int) bool {
+			return g.Players[clockwise[i]].Score < g.Players[clockwise[j]].Score
+		})
+	}
+	return clockwise
+}
+
+// runSecretActions has every player choose a manipulation while the board is
+// as the trick left it, then makes the choices in order. A choice is
+// cancelled if the manipulations made before it leave it illegal, such as a
+// flip of a slot that is now locked, or if it would change a slot one of
+// them already changed.
+func (g *Game) runSecretActions(order []int) {
+	type choice struct {
+		player *Player
+		action Action
+	}
+	choices := []choice{}
+	for _, playerIdx := range order {
+		player := g.Players[playerIdx]
+		if len(g.legalActions(player, nil)) == 0 {
+			continue
+		}
+		choices = append(choices, choice{player, g.agent(playerIdx).ChooseAction(g, player, nil)})
+	}
+
+	var previousAction *Action
+	for _, c := range choices {
+		action := c.action
+		conflict := g.actionConflict(c.player, action, previousAction)
+		if g.Stats != nil {
+			g.Stats.SecretActions++
+			if conflict != "" {
+				g.Stats.CancelledActions++
+			}
+		}
+		if conflict != "" {
+			if g.Verbose {
+				fmt.Printf("Player %d's choice to %s is cancelled: %s\n", c.player.ID, action, conflict)
+			}
+			g.emit(Event{Kind: EventAction, PlayerID: c.player.ID, Action: &action, Cancelled: true})
+			continue
+		}
+		g.makeAction(c.player, action)
+		previousAction = &action
+	}
+}
+
+// actionConflict explains why a secretly chosen action cannot be made after
+// the ones already made this round, or returns "" if it can
+func (g *Game) actionConflict(player *Player, action Action, previousAction *Action) string {
+	if !slices.ContainsFunc(g.legalActions(player, previousAction), func(a Action) bool { return actionsMatch(a, action) }) {
+		return "it is no longer allowed"
+	}
+	after := g.Board.clone()
+	after.apply(action)
+	for slot := range after.Slots {
+		if sameSlot(g.Board, after, slot) {
+			continue
+		}
+		for _, m := range g.Manipulations {
+			if m.changes(slot) {
+				return fmt.Sprintf("Player %d already changed Slot %d", m.PlayerID, slot+1)
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// scriptedAgent presents its first bee, makes the manipulation it is given
+// and otherwise plays greedily
+type scriptedAgent struct {
+	GreedyAgent
+	action Action
+}
+
+func (a scriptedAgent) ChooseCard(g *Game, player *Player) int {
+	return 0
+}
+
+func (a scriptedAgent) ChooseAction(g *Game, player *Player, previousAction *Action) Action {
+	return a.action
+}
+
+func TestSecretActionConflicts(t *testing.T) {
+	flip := func(slot int) Action { return Action{Type: "flip", SlotIndex: slot} }
+	swap := func(slot, slot2 int) Action { return Action{Type: "swap", SlotIndex: slot, SlotIndex2: slot2} }
+	lock := func(slot int) Action { return Action{Type: "lock", SlotIndex: slot} }
+
+	tests := []struct {
+		name     string
+		noRepeat string
+		scores   []int
+		choices  []Action // By seat
+		made     []int    // Seats whose choices are made, in the order they are made
+	}{
+		{"separate slots", NoRepeatNone, []int{0, 1, 2}, []Action{flip(0), flip(2), swap(3, 4)}, []int{0, 1, 2}},
+		{"same flip", NoRepeatNone, []int{0, 1, 2}, []Action{flip(1), flip(1), flip(2)}, []int{0, 2}},
+		{"swap over a flip", NoRepeatNone, []int{0, 1, 2}, []Action{flip(1), swap(1, 3), swap(3, 4)}, []int{0, 2}},
+		{"swap over a swap", NoRepeatNone, []int{0, 1, 2}, []Action{swap(0, 5), swap(4, 5), flip(4)}, []int{0, 2}},
+		{"locked slot", NoRepeatNone, []int{0, 1, 2}, []Action{lock(2), flip(2), swap(2, 3)}, []int{0}},
+		{"copying the previous", NoRepeatPrevious, []int{0, 1, 2}, []Action{flip(1), flip(1), flip(1)}, []int{0}},
+		{"repeat in the round", NoRepeatRound, []int{0, 1, 2}, []Action{lock(0), flip(3), lock(0)}, []int{0, 1}},
+		{"lowest score first", NoRepeatNone, []int{3, 1, 2}, []Action{flip(1), flip(1), swap(1, 2)}, []int{1}},
+		{"ties from the leader", NoRepeatNone, []int{1, 0, 0}, []Action{flip(2), lock(0), flip(0)}, []int{1, 0}},
+	}
+	for _, tt := range tests {
+		game := NewSeededGame(3, 1, false)
+		game.Rules.Actions = []string{"flip", "swap", "lock"}
+		game.Rules.NoRepeat = tt.noRepeat
+		game.Rules.ActionOrder = ActionOrderSecret
+		game.CurrentLeader = 1
+		for slot := range game.Board.Slots {
+			game.Board.Slots[slot] = &AttributeToken{Attribute: Attribute(slot), Edition: printedEdition}
+		}
+		for seat, player := range game.Players {
+			player.Score = tt.scores[seat]
+			game.Agents = append(game.Agents, scriptedAgent{action: tt.choices[seat]})
+		}
+
+		game.runSecretActions(game.actionOrder())
+		made := []int{}
+		for _, m := range game.Manipulations {
+			made = append(made, m.PlayerID)
+		}
+		if !slices.Equal(made, tt.made) {
+			t.Errorf("%s: made the choices of seats %v, want %v", tt.name, made, tt.made)
+		}
+	}
+}
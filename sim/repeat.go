@@ -0,0 +1,94 @@
+package main
+
+import "strings"
+
+// No-repeat rules, set by RuleConfig.NoRepeat: which manipulations the ones
+// already made this round rule out
+const (
+	NoRepeatPrevious = "previous" // No copying the previous player's action (the published rule)
+	NoRepeatNone     = "none"     // Any manipulation at any time
+	NoRepeatRound    = "round"    // No action may be made twice in a round
+	NoRepeatUndo     = "undo"     // No action that puts back what the previous one changed, such as swapping the same slots again
+	NoRepeatSlot1    = "slot1"    // Only one action a round may change Slot 1
+)
+
+// noRepeatRules lists the rules in the order error messages give them
+var noRepeatRules = []string{NoRepeatPrevious, NoRepeatNone, NoRepeatRound, NoRepeatUndo, NoRepeatSlot1}
+
+// Manipulation is one action made this round, with the board around it
+type Manipulation struct {
+	PlayerID int
+	Action   Action
+	Before   ProtocolBoard // The board just before the action
+	After    ProtocolBoard // The board just after it
+}
+
+// changes reports whether the manipulation changed the tile in slot, turned
+// it or locked it
+func (m Manipulation) changes(slot int) bool {
+	return !sameSlot(m.Before, m.After, slot)
+}
+
+// sameSlot reports whether two boards hold the same tile, on the same side
+// and equally locked, in slot
+func sameSlot(b1, b2 ProtocolBoard, slot int) bool {
+	t1, t2 := b1.Slots[slot], b2.Slots[slot]
+	if (t1 == nil) != (t2 == nil) || (t1 != nil && *t1 != *t2) {
+		return false
+	}
+	return b1.Locked[slot] == b2.Locked[slot]
+}
+
+// sameBoard reports whether two boards are the same in every slot
+func sameBoard(b1, b2 ProtocolBoard) bool {
+	for slot := range b1.Slots {
+		if !sameSlot(b1, b2, slot) {
+			return false
+		}
+	}
+	return true
+}
+
+// repeatForbidden reports whether the rule forbids making action on board,
+// after the manipulations in history have been made this round
+func repeatForbidden(rule string, history []Manipulation, board ProtocolBoard, action Action) bool {
+	switch rule {
+	case NoRepeatNone:
+		return false
+	case NoRepeatRound:
+		for _, m := range history {
+			if actionsMatch(m.Action, action) {
+				return true
+			}
+		}
+		return false
+	case NoRepeatUndo:
+		if len(history) == 0 {
+			return false
+		}
+		last := history[len(history)-1]
+		if sameBoard(last.Before, last.After) {
+			return false // Nothing to undo
+		}
+		after := board.clone()
+		after.apply(action)
+		return sameBoard(after, last.Before)
+	case NoRepeatSlot1:
+		touched := false
+		for _, m := range history {
+			touched = touched || m.changes(0)
+		}
+		if !touched {
+			return false
+		}
+		after := board.clone()
+		after.apply(action)
+		return !sameSlot(board, after, 0)
+	}
+	return len(history) > 0 && actionsMatch(history[len(history)-1].Action, action)
+}
+
+// noRepeatNames lists the rules for error messages
+func noRepeatNames() string {
+	return strings.Join(noRepeatRules, ", ")
+}
@@ -0,0 +1,74 @@
+package main
+
+import "testing"
+
+// playHistory makes actions in turn on a fresh three-slot board, returning
+// the manipulations and the board they leave
+func playHistory(actions ...Action) ([]Manipulation, ProtocolBoard) {
+	board := newBoard(3)
+	for slot, attr := range []Attribute{Texture, Antennae, Weapon} {
+		board.Slots[slot] = &AttributeToken{Attribute: attr, Value: slot % 2, Edition: printedEdition}
+	}
+	history := []Manipulation{}
+	for i, action := range actions {
+		before := board.clone()
+		board.apply(action)
+		history = append(history, Manipulation{PlayerID: i, Action: action, Before: before, After: board.clone()})
+	}
+	return history, board
+}
+
+func TestRepeatForbidden(t *testing.T) {
+	flip0 := Action{Type: "flip", SlotIndex: 0}
+	flip1 := Action{Type: "flip", SlotIndex: 1}
+	swap01 := Action{Type: "swap", SlotIndex: 0, SlotIndex2: 1}
+	swap02 := Action{Type: "swap", SlotIndex: 0, SlotIndex2: 2}
+	swap20 := Action{Type: "swap", SlotIndex: 2, SlotIndex2: 0}
+	swap12 := Action{Type: "swap", SlotIndex: 1, SlotIndex2: 2}
+	lock0 := Action{Type: "lock", SlotIndex: 0}
+	pass := Action{Type: "pass"}
+
+	// Most cases follow a flip of Slot 2 and then a swap of Slots 1 and 3
+	round := []Action{flip1, swap02}
+	tests := []struct {
+		rule      string
+		history   []Action
+		action    Action
+		forbidden bool
+	}{
+		{NoRepeatPrevious, round, swap02, true},
+		{NoRepeatPrevious, round, swap20, true},
+		{NoRepeatPrevious, round, flip1, false},
+		{NoRepeatPrevious, nil, flip1, false},
+
+		{NoRepeatNone, round, swap02, false},
+		{NoRepeatNone, round, flip1, false},
+
+		{NoRepeatRound, round, swap02, true},
+		{NoRepeatRound, round, flip1, true},
+		{NoRepeatRound, round, flip0, false},
+		{NoRepeatRound, round, swap12, false},
+
+		{NoRepeatUndo, round, swap02, true},
+		{NoRepeatUndo, round, swap20, true},
+		{NoRepeatUndo, round, flip1, false}, // Undoes the first action, not the last
+		{NoRepeatUndo, round, flip0, false},
+		{NoRepeatUndo, []Action{flip1}, flip1, true},
+		{NoRepeatUndo, []Action{flip1, pass}, flip1, false}, // A pass leaves nothing to undo
+		{NoRepeatUndo, nil, flip1, false},
+
+		{NoRepeatSlot1, round, flip0, true},
+		{NoRepeatSlot1, round, swap01, true},
+		{NoRepeatSlot1, round, lock0, true},
+		{NoRepeatSlot1, round, flip1, false},
+		{NoRepeatSlot1, round, swap12, false},
+		{NoRepeatSlot1, []Action{flip1, swap12}, flip0, false}, // Slot 1 untouched so far
+		{NoRepeatSlot1, []Action{lock0}, flip1, false},
+	}
+	for _, tt := range tests {
+		history, board := playHistory(tt.history...)
+		if got := repeatForbidden(tt.rule, history, board, tt.action); got != tt.forbidden {
+			t.Errorf("%s after %v: %s forbidden = %v, want %v", tt.rule, tt.history, tt.action, got, tt.forbidden)
+		}
+	}
+}
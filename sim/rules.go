@@ -0,0 +1,269 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// RuleConfig holds the rule parameters a game can vary. The zero value is
+// not playable; start from DefaultRules.
+type RuleConfig struct {
+	HandSize      int            `json:"hand_size"`                 // Cards dealt to each player per hand
+	TargetTricks  int            `json:"target_tricks"`             // Tricks needed to win
+	Scoring       string         `json:"scoring,omitempty"`         // What tricks are worth, such as "cards"; "" for a point per trick
+	TargetScore   int            `json:"target_score,omitempty"`    // Points needed to win under Scoring; 0 for the mode's own target
+	Attributes    []AttributeDef `json:"attributes,omitempty"`      // The edition's attributes; nil for the printed six
+	Draft         string         `json:"draft,omitempty"`           // Built-in draft schedule; "" for standard
+	DraftSchedule DraftSchedule  `json:"draft_schedule,omitempty"`  // Turns for the player counts they list, replacing Draft
+	Auction       string         `json:"auction,omitempty"`         // "points" or "cards" to bid for the slots instead of drafting them
+	Actions       []string       `json:"actions,omitempty"`         // Manipulations allowed, in the order AIs consider them; nil for flip and swap
+	NoRepeat      string         `json:"no_repeat,omitempty"`       // Which earlier manipulations rule one out; "" for copying the previous one
+	ActionOrder   string         `json:"action_order,omitempty"`    // Who manipulates first, or "secret" for everyone at once; "" for the trick winner
+	Judge         string         `json:"judge,omitempty"`           // "points" to total weighted slots instead of judging slot by slot
+	SlotWeights   []int          `json:"slot_weights,omitempty"`    // The points judge's weight for each slot; nil for falling weights
+	JudgeTieBreak string         `json:"judge_tie_break,omitempty"` // How the points judge settles equal totals; "" for slot by slot
+	Decks         int            `json:"decks,omitempty"`           // Copies of every bee shuffled together; 0 for a single deck
+	Ties          string         `json:"ties,omitempty"`            // What happens to cards the Judge cannot separate; "" for closest to the leader
+}
+
+// MaxDecks is the most copies of the deck a game can be played with
+const MaxDecks = 4
+
+// DefaultRules returns the rules of the published game
+func DefaultRules() RuleConfig {
+	return RuleConfig{
+		HandSize:     7,
+		TargetTricks: 10,
+	}
+}
+
+// configuredRules are the rules NewGame plays by; replaced by the --rules flag
+var configuredRules = DefaultRules()
+
+// LoadRules reads rules from a JSON file such as rules/five-attributes.json
+func LoadRules(path string) (RuleConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return RuleConfig{}, err
+	}
+	var rules RuleConfig
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return RuleConfig{}, fmt.Errorf("%s: %v", path, err)
+	}
+	return rules, nil
+}
+
+// UnmarshalJSON fills in any rule the JSON leaves out from DefaultRules
+func (r *RuleConfig) UnmarshalJSON(data []byte) error {
+	type plain RuleConfig // Without this method, to avoid recursion
+	rules := plain(DefaultRules())
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return err
+	}
+	*r = RuleConfig(rules)
+	return nil
+}
+
+// Edition returns the edition the rules' attributes describe
+func (r RuleConfig) Edition() *Edition {
+	if r.Attributes == nil {
+		return printedEdition
+	}
+	return EditionFor(r.Attributes)
+}
+
+// DeckCount is the number of copies of every bee in the game
+func (r RuleConfig) DeckCount() int {
+	return max(r.Decks, 1)
+}
+
+// DeckSize is the number of bees in the game, counting every copy
+func (r RuleConfig) DeckSize() int {
+	return r.Edition().DeckSize() * r.DeckCount()
+}
+
+// DraftName names the draft schedule for reports
+func (r RuleConfig) DraftName() string {
+	switch {
+	case r.Auction != "":
+		return r.Auction + " auction"
+	case len(r.DraftSchedule) > 0:
+		return "custom"
+	case r.Draft == "":
+		return "standard"
+	}
+	return r.Draft
+}
+
+// DraftTurns returns the turns of the draft at a table of numPlayers
+func (r RuleConfig) DraftTurns(numPlayers int) []DraftTurn {
+	if turns, ok := r.DraftSchedule[numPlayers]; ok {
+		return turns
+	}
+	schedule, ok := draftSchedules[r.Draft]
+	if !ok {
+		schedule = standardDraft
+	}
+	return schedule(numPlayers, r.Edition().NumAttributes())
+}
+
+// ActionNames lists the kinds of manipulation the rules allow
+func (r RuleConfig) ActionNames() []string {
+	if r.Actions == nil {
+		return defaultActions
+	}
+	return r.Actions
+}
+
+// NoRepeatRule names the rule limiting repeated manipulations
+func (r RuleConfig) NoRepeatRule() string {
+	if r.NoRepeat == "" {
+		return NoRepeatPrevious
+	}
+	return r.NoRepeat
+}
+
+// ActionOrderRule names the order players manipulate in
+func (r RuleConfig) ActionOrderRule() string {
+	if r.ActionOrder == "" {
+		return ActionOrderWinner
+	}
+	return r.ActionOrder
+}
+
+// ScoringName names the scoring mode
+func (r RuleConfig) ScoringName() string {
+	if r.Scoring == "" {
+		return "tricks"
+	}
+	return r.Scoring
+}
+
+// Target is the score that wins at a table of numPlayers
+func (r RuleConfig) Target(numPlayers int) int {
+	if r.TargetScore > 0 {
+		return r.TargetScore
+	}
+	return scoringModes[r.ScoringName()].Target(r, numPlayers)
+}
+
+// JudgeName names the way tricks are judged
+func (r RuleConfig) JudgeName() string {
+	if r.Judge == "" {
+		return JudgeSlots
+	}
+	return r.Judge
+}
+
+// TieBreakName names the way the points judge settles equal totals
+func (r RuleConfig) TieBreakName() string {
+	if r.JudgeTieBreak == "" {
+		return TieBreakSlots
+	}
+	return r.JudgeTieBreak
+}
+
+// TiesName names what happens to cards the Judge cannot separate
+func (r RuleConfig) TiesName() string {
+	if r.Ties == "" {
+		return TiesClosest
+	}
+	return r.Ties
+}
+
+// Weights returns the points judge's weight for each of numSlots slots
+func (r RuleConfig) Weights(numSlots int) []int {
+	if r.SlotWeights == nil {
+		return defaultWeights(numSlots)
+	}
+	return r.SlotWeights
+}
+
+// Validate checks that the rules can be played with the given player count
+func (r RuleConfig) Validate(numPlayers int) error {
+	edition := r.Edition()
+	if err := edition.Validate(); err != nil {
+		return err
+	}
+	if edition.NumAttributes() < numPlayers && r.Auction == "" {
+		return fmt.Errorf("%d players need at least %d attributes so everyone drafts", numPlayers, numPlayers)
+	}
+	if r.Decks < 0 || r.Decks > MaxDecks {
+		return fmt.Errorf("decks must be between 1 and %d, or 0 for one", MaxDecks)
+	}
+	if r.HandSize < 1 || r.HandSize*numPlayers > r.DeckSize() {
+		return fmt.Errorf("hand size must be between 1 and %d for %d players", r.DeckSize()/numPlayers, numPlayers)
+	}
+	switch r.Auction {
+	case "", AuctionPoints, AuctionCards:
+	default:
+		return fmt.Errorf("auction bids are paid in %q or %q, not %q", AuctionPoints, AuctionCards, r.Auction)
+	}
+	if r.Auction != "" && (r.Draft != "" || len(r.DraftSchedule) > 0) {
+		return fmt.Errorf("an auction replaces the draft schedule; set one or the other")
+	}
+	if _, ok := draftSchedules[r.Draft]; !ok && r.Draft != "" {
+		return fmt.Errorf("no draft schedule is called %q; the built-in ones are %s", r.Draft, draftScheduleNames())
+	}
+	if turns, ok := r.DraftSchedule[numPlayers]; ok {
+		if err := validateDraft(turns, numPlayers, edition.NumAttributes()); err != nil {
+			return fmt.Errorf("%d-player draft: %v", numPlayers, err)
+		}
+	}
+	if r.Actions != nil && len(r.Actions) == 0 {
+		return fmt.Errorf("the rules must allow at least one manipulation")
+	}
+	for i, name := range r.Actions {
+		if _, ok := actionKinds[name]; !ok {
+			return fmt.Errorf("no manipulation is called %q; they are %s", name, actionKindNames())
+		}
+		if slices.Contains(r.Actions[:i], name) {
+			return fmt.Errorf("%s is listed twice in the manipulations", name)
+		}
+	}
+	if !slices.Contains(noRepeatRules, r.NoRepeatRule()) {
+		return fmt.Errorf("no no-repeat rule is called %q; they are %s", r.NoRepeat, noRepeatNames())
+	}
+	if !slices.Contains(actionOrderRules, r.ActionOrderRule()) {
+		return fmt.Errorf("no manipulation order is called %q; they are %s", r.ActionOrder, strings.Join(actionOrderRules, ", "))
+	}
+	if r.TargetTricks < 1 {
+		return fmt.Errorf("target tricks must be at least 1")
+	}
+	if !slices.Contains(judgeNames, r.JudgeName()) {
+		return fmt.Errorf("no judge is called %q; they are %s", r.Judge, strings.Join(judgeNames, ", "))
+	}
+	if !slices.Contains(tieBreakNames, r.TieBreakName()) {
+		return fmt.Errorf("no tie-break is called %q; they are %s", r.JudgeTieBreak, strings.Join(tieBreakNames, ", "))
+	}
+	if r.JudgeName() != JudgePoints && (r.SlotWeights != nil || r.JudgeTieBreak != "") {
+		return fmt.Errorf("slot weights and judge tie-breaks need the points judge")
+	}
+	if !slices.Contains(tiesNames, r.TiesName()) {
+		return fmt.Errorf("no tie rule is called %q; they are %s", r.Ties, strings.Join(tiesNames, ", "))
+	}
+	if r.JudgeName() == JudgePoints && r.TieBreakName() != TieBreakSlots && r.Ties != "" {
+		return fmt.Errorf("the points judge's %s tie-break already settles ties; drop it to set ties", r.TieBreakName())
+	}
+	if r.SlotWeights != nil && len(r.SlotWeights) != edition.NumAttributes() {
+		return fmt.Errorf("there are %d slot weights for %d slots", len(r.SlotWeights), edition.NumAttributes())
+	}
+	for i, weight := range r.SlotWeights {
+		if weight < 0 {
+			return fmt.Errorf("slot weights cannot be negative, but Slot %d's is %d", i+1, weight)
+		}
+	}
+	if _, ok := scoringModes[r.ScoringName()]; !ok {
+		return fmt.Errorf("no scoring mode is called %q; they are %s", r.Scoring, scoringNames())
+	}
+	if r.TargetScore < 0 {
+		return fmt.Errorf("target score must be at least 1, or 0 for the scoring mode's own")
+	}
+	if r.Target(numPlayers) < 1 {
+		return fmt.Errorf("%s scoring needs a target score of at least 1", r.ScoringName())
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Scoring decides what winning tricks is worth. Every mode is registered in
+// scoringModes under the name RuleConfig.Scoring gives it. Targets are
+// chosen so that games last about as many hands as the published game.
+type Scoring interface {
+	// TrickPoints is what the winner scores for a trick the Judge decided
+	// after judging the given number of slots, capturing the given number of
+	// bees
+	TrickPoints(g *Game, winnerID int, judged int, captured int) int
+
+	// HandPoints is what a player scores at the end of a hand for the bees
+	// in their score pile
+	HandPoints(g *Game, player *Player) int
+
+	// Target is the score that wins when the rules do not set one
+	Target(r RuleConfig, numPlayers int) int
+}
+
+// scoringModes holds every way of scoring by name
+var scoringModes = map[string]Scoring{
+	"tricks":         trickScoring{},
+	"cards":          cardScoring{},
+	"depth":          depthScoring{},
+	"streak-bonus":   streakScoring{bonus: true},
+	"streak-penalty": streakScoring{},
+	"sets":           setScoring{},
+}
+
+// scoringNames lists the registered modes for error messages
+func scoringNames() string {
+	names := make([]string, 0, len(scoringModes))
+	for name := range scoringModes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// scoring returns the game's scoring mode
+func (g *Game) scoring() Scoring {
+	return scoringModes[g.Rules.ScoringName()]
+}
+
+// scoreLabel names n points the way the scoring counts them, such as
+// "3 tricks"
+func (g *Game) scoreLabel(n int) string {
+	if g.Rules.ScoringName() == "tricks" {
+		return fmt.Sprintf("%d tricks", n)
+	}
+	return fmt.Sprintf("%d points", n)
+}
+
+// wonPrevious reports whether the trick's winner also won the previous trick
+// of this hand
+func (g *Game) wonPrevious(winnerID int) bool {
+	// The previous trick's winner leads this one
+	return g.TrickNumber > 1 && g.CurrentLeader == winnerID
+}
+
+// trickScoring is the published game: a point per trick
+type trickScoring struct{}
+
+func (trickScoring) TrickPoints(g *Game, winnerID int, judged int, captured int) int { return 1 }
+func (trickScoring) HandPoints(g *Game, player *Player) int                          { return 0 }
+func (trickScoring) Target(r RuleConfig, numPlayers int) int                         { return r.TargetTricks }
+
+// cardScoring scores a point per bee captured, so bigger tables and
+// replayed ties give bigger tricks
+type cardScoring struct{}
+
+func (cardScoring) TrickPoints(g *Game, winnerID int, judged int, captured int) int { return captured }
+func (cardScoring) HandPoints(g *Game, player *Player) int                          { return 0 }
+func (cardScoring) Target(r RuleConfig, numPlayers int) int                         { return r.TargetTricks * numPlayers }
+
+// depthScoring scores a trick by the slot that decided it: a point for Slot
+// 1, two for Slot 2, and so on, so close tricks are worth more
+type depthScoring struct{}
+
+func (depthScoring) TrickPoints(g *Game, winnerID int, judged int, captured int) int {
+	return max(judged, 1)
+}
+func (depthScoring) HandPoints(g *Game, player *Player) int  { return 0 }
+func (depthScoring) Target(r RuleConfig, numPlayers int) int { return r.TargetTricks * 3 }
+
+// streakScoring changes the worth of a trick won straight after the previous
+// one in the same hand: two points with the bonus, none with the penalty.
+// A hand's first trick always scores, so every hand moves the game on.
+type streakScoring struct {
+	bonus bool
+}
+
+func (s streakScoring) TrickPoints(g *Game, winnerID int, judged int, captured int) int {
+	switch {
+	case !g.wonPrevious(winnerID):
+		return 1
+	case s.bonus:
+		return 2
+	}
+	return 0
+}
+
+func (streakScoring) HandPoints(g *Game, player *Player) int { return 0 }
+
+func (s streakScoring) Target(r RuleConfig, numPlayers int) int {
+	if s.bonus {
+		return r.TargetTricks * 5 / 4
+	}
+	return r.TargetTricks * 4 / 5
+}
+
+// setScoring scores nothing for tricks. At the end of each hand, every
+// attribute scores a point for each complete set of its values among the
+// bees a player captured, such as a Fuzzy and a Shiny bee. Sudden death is
+// decided trick by trick, so there each trick scores a point; otherwise
+// players who capture equal sets every hand would stay tied for ever.
+type setScoring struct{}
+
+func (setScoring) TrickPoints(g *Game, winnerID int, judged int, captured int) int {
+	if g.SuddenDeath {
+		return 1
+	}
+	return 0
+}
+
+func (setScoring) HandPoints(g *Game, player *Player) int {
+	edition := g.Edition()
+	points := 0
+	for _, attr := range edition.AllAttributes() {
+		counts := valueCounts(player.ScorePile, attr, edition.NumValues(attr))
+		sets := counts[0]
+		for _, count := range counts {
+			sets = min(sets, count)
+		}
+		points += sets
+	}
+	return points
+}
+
+func (setScoring) Target(r RuleConfig, numPlayers int) int { return r.TargetTricks * 4 }
@@ -0,0 +1,454 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Move is one decision made by a remote seat. Only the fields for its kind
+// are set; slots and card indices are 0-based.
+type Move struct {
+	Kind      string  `json:"kind"`                // "draft", "present", "manipulate", "bid" or "discard"
+	Attribute string  `json:"attribute,omitempty"` // draft: attribute name, e.g. "Texture"
+	Value     string  `json:"value,omitempty"`     // draft: value name, e.g. "Shiny"
+	Card      *int    `json:"card,omitempty"`      // present, discard: index into the hand
+	Action    *Action `json:"action,omitempty"`    // manipulate
+	Bid       *int    `json:"bid,omitempty"`       // bid: tricks, points or cards offered
+}
+
+// matches reports whether two moves make the same decision
+func (m Move) matches(other Move) bool {
+	if m.Kind != other.Kind {
+		return false
+	}
+	switch m.Kind {
+	case "draft":
+		return strings.EqualFold(m.Attribute, other.Attribute) && strings.EqualFold(m.Value, other.Value)
+	case "present", "discard":
+		return m.Card != nil && other.Card != nil && *m.Card == *other.Card
+	case "manipulate":
+		return m.Action != nil && other.Action != nil && actionsMatch(*m.Action, *other.Action)
+	case "bid":
+		return m.Bid != nil && other.Bid != nil && *m.Bid == *other.Bid
+	}
+	return false
+}
+
+// Decision is a choice the game is waiting for a remote seat to make. In an
+// auction draft the seat bids for each slot, and when a card bid wins it
+// discards the bees it pays with one at a time.
+type Decision struct {
+	Kind      string  `json:"kind"`                // "draft", "present", "manipulate", "bid" or "discard"
+	Slot      *int    `json:"slot,omitempty"`      // draft, bid: the slot being filled or sold
+	Forbidden *Action `json:"forbidden,omitempty"` // manipulate: the previous player's action
+	Remaining *int    `json:"remaining,omitempty"` // discard: bees still to discard, counting this one
+	Moves     []Move  `json:"moves"`               // Every legal move
+}
+
+// legalMove returns the legal move matching m
+func (d *Decision) legalMove(m Move) (Move, bool) {
+	for _, legal := range d.Moves {
+		if legal.matches(m) {
+			return legal, true
+		}
+	}
+	return Move{}, false
+}
+
+var (
+	errNotYourTurn = errors.New("this seat has no decision to make")
+	errIllegalMove = errors.New("illegal move")
+	errGameOver    = errors.New("the game is over")
+)
+
+// Session runs a game whose remote seats are played through method calls,
+// for servers where moves arrive from other goroutines. The engine runs in
+// its own goroutine and holds the session lock except while it waits for a
+// remote move, so views always see a settled game.
+type Session struct {
+	ID     string
+	Remote []bool // Seats played through Submit
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	game    *Game
+	pending map[int]*Decision // Decisions open to each remote seat
+	standIn map[int]Agent     // Agents playing remote seats whose players have gone
+	inbox   map[int]Move      // Moves submitted but not yet taken by the engine
+	waiting int               // Remote seat the engine is blocked on, or -1
+	opened  map[int]trickKey  // Trick whose Present decision each seat was offered
+	last    []Play            // Cards of the last completed trick
+	winner  int               // -1 until the game is over
+	closed  bool
+}
+
+// NewSession prepares a game with the given agents, where nil agents mark
+// remote seats. Call Start to begin play.
+func NewSession(id string, game *Game, agents []Agent) *Session {
+	s := &Session{
+		ID:      id,
+		Remote:  make([]bool, len(agents)),
+		game:    game,
+		pending: make(map[int]*Decision),
+		standIn: make(map[int]Agent),
+		inbox:   make(map[int]Move),
+		waiting: -1,
+		opened:  make(map[int]trickKey),
+		winner:  -1,
+	}
+	s.cond = sync.NewCond(&s.mu)
+
+	game.Agents = make([]Agent, len(agents))
+	for seat, agent := range agents {
+		if agent == nil {
+			s.Remote[seat] = true
+			agent = &remoteSeat{s: s, seat: seat}
+		}
+		game.Agents[seat] = agent
+	}
+	game.Observers = append(game.Observers, s)
+	return s
+}
+
+// Start runs the game in the background and returns once it needs a remote
+// move or is over
+func (s *Session) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	go s.run()
+	s.settle()
+}
+
+// run plays the game while holding the lock
+func (s *Session) run() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.winner = s.game.Run()
+	s.pending = make(map[int]*Decision)
+	s.cond.Broadcast()
+}
+
+// settle waits until the engine is blocked on a remote seat or finished.
+// The caller holds the lock.
+func (s *Session) settle() {
+	for s.waiting == -1 && s.winner == -1 {
+		s.cond.Wait()
+	}
+}
+
+// Close abandons the game; remote seats without a stand-in play greedily so
+// it runs to the end
+func (s *Session) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.cond.Broadcast()
+}
+
+// Submit makes a move for a remote seat and returns once the game has
+// settled again
+func (s *Session) Submit(seat int, m Move) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.winner != -1 {
+		return errGameOver
+	}
+	d := s.pending[seat]
+	if d == nil {
+		return errNotYourTurn
+	}
+	legal, ok := d.legalMove(m)
+	if !ok {
+		return errIllegalMove
+	}
+
+	delete(s.pending, seat)
+	s.inbox[seat] = legal
+	if s.waiting == seat {
+		s.waiting = -1
+		s.cond.Broadcast()
+		s.settle()
+	}
+	return nil
+}
+
+// Substitute hands a remote seat to an agent for the rest of the game, as
+// when its player has gone for good, and returns once the game has settled
+// again. A move the player already committed is still played.
+func (s *Session) Substitute(seat int, agent Agent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if seat < 0 || seat >= len(s.Remote) || !s.Remote[seat] || s.winner != -1 {
+		return
+	}
+	s.standIn[seat] = agent
+	delete(s.pending, seat)
+	if s.waiting == seat {
+		s.waiting = -1
+		s.cond.Broadcast()
+		s.settle()
+	}
+}
+
+// Decision returns the decision open to a seat, or nil
+func (s *Session) Decision(seat int) *Decision {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pending[seat]
+}
+
+// Observe remembers the last trick for views
+func (s *Session) Observe(g *Game, e Event) {
+	switch e.Kind {
+	case EventTrickWon:
+		s.last = e.Plays
+	case EventHandStart:
+		s.last = nil
+	}
+}
+
+// await blocks the engine until the seat's move arrives. The engine holds
+// the lock, which cond.Wait releases while it waits.
+func (s *Session) await(seat int, d *Decision) (Move, bool) {
+	if _, committed := s.inbox[seat]; !committed && s.pending[seat] == nil && s.standIn[seat] == nil {
+		s.pending[seat] = d
+	}
+	for {
+		if m, ok := s.inbox[seat]; ok {
+			delete(s.inbox, seat)
+			return m, true
+		}
+		if s.closed || s.standIn[seat] != nil {
+			delete(s.pending, seat)
+			return Move{}, false
+		}
+		s.waiting = seat
+		s.cond.Broadcast()
+		s.cond.Wait()
+	}
+}
+
+// trickKey identifies a trick within a game
+type trickKey struct {
+	hand, trick int
+}
+
+// openPresents offers the Present decision to every remote seat at once, so
+// remote players commit their cards simultaneously rather than in turn
+func (s *Session) openPresents() {
+	trick := trickKey{s.game.HandNumber, s.game.TrickNumber}
+	for seat, remote := range s.Remote {
+		if remote && s.standIn[seat] == nil && s.opened[seat] != trick {
+			s.opened[seat] = trick
+			s.pending[seat] = presentDecision(s.game.Players[seat])
+		}
+	}
+}
+
+// draftDecision lists every token the seat could place
+func draftDecision(edition *Edition, availableTokens []Attribute, slotIdx int) *Decision {
+	d := &Decision{Kind: "draft", Slot: &slotIdx}
+	for _, option := range tokenOptions(edition, availableTokens) {
+		d.Moves = append(d.Moves, Move{
+			Kind:      "draft",
+			Attribute: edition.AttributeName(option.Attribute),
+			Value:     edition.ValueName(option.Attribute, option.Value),
+		})
+	}
+	return d
+}
+
+// presentDecision lists every card the player could present
+func presentDecision(player *Player) *Decision {
+	d := &Decision{Kind: "present"}
+	for i := range player.Hand {
+		card := i
+		d.Moves = append(d.Moves, Move{Kind: "present", Card: &card})
+	}
+	return d
+}
+
+// manipulateDecision lists every legal manipulation
+func (g *Game) manipulateDecision(player *Player, previousAction *Action) *Decision {
+	d := &Decision{Kind: "manipulate", Forbidden: previousAction}
+	for _, action := range g.legalActions(player, previousAction) {
+		a := action
+		d.Moves = append(d.Moves, Move{Kind: "manipulate", Action: &a})
+	}
+	return d
+}
+
+// bidDecision lists every bid the seat could make for a slot
+func bidDecision(slotIdx, maxBid int) *Decision {
+	d := &Decision{Kind: "bid", Slot: &slotIdx}
+	for n := 0; n <= maxBid; n++ {
+		bid := n
+		d.Moves = append(d.Moves, Move{Kind: "bid", Bid: &bid})
+	}
+	return d
+}
+
+// discardDecision lists the bees the player could discard next, leaving
+// out those already chosen
+func discardDecision(player *Player, chosen map[int]bool, remaining int) *Decision {
+	d := &Decision{Kind: "discard", Remaining: &remaining}
+	for i := range player.Hand {
+		if !chosen[i] {
+			card := i
+			d.Moves = append(d.Moves, Move{Kind: "discard", Card: &card})
+		}
+	}
+	return d
+}
+
+// remoteSeat is the agent for a seat played through the session
+type remoteSeat struct {
+	s    *Session
+	seat int
+}
+
+// fallback plays the seat when no move will come: its stand-in if it has
+// one, otherwise greedily
+func (r *remoteSeat) fallback() Agent {
+	if agent := r.s.standIn[r.seat]; agent != nil {
+		return agent
+	}
+	return GreedyAgent{}
+}
+
+// fallbackBidder bids for the seat when no move will come
+func (r *remoteSeat) fallbackBidder() Bidder {
+	if b, ok := r.fallback().(Bidder); ok {
+		return b
+	}
+	return BaselineBidder{}
+}
+
+func (r *remoteSeat) ChooseToken(g *Game, player *Player, availableTokens []Attribute, slotIdx int) (Attribute, int) {
+	edition := g.Edition()
+	m, ok := r.s.await(r.seat, draftDecision(edition, availableTokens, slotIdx))
+	if !ok {
+		return r.fallback().ChooseToken(g, player, availableTokens, slotIdx)
+	}
+	for _, attr := range availableTokens {
+		if strings.EqualFold(edition.AttributeName(attr), m.Attribute) {
+			value, _ := edition.ParseValue(m.Value, attr)
+			return attr, value
+		}
+	}
+	panic(fmt.Sprintf("draft move %+v passed validation", m))
+}
+
+func (r *remoteSeat) ChooseCard(g *Game, player *Player) int {
+	r.s.openPresents()
+	m, ok := r.s.await(r.seat, presentDecision(player))
+	if !ok {
+		return r.fallback().ChooseCard(g, player)
+	}
+	return *m.Card
+}
+
+func (r *remoteSeat) ChooseAction(g *Game, player *Player, previousAction *Action) Action {
+	m, ok := r.s.await(r.seat, g.manipulateDecision(player, previousAction))
+	if !ok {
+		return r.fallback().ChooseAction(g, player, previousAction)
+	}
+	return *m.Action
+}
+
+func (r *remoteSeat) ChooseBid(g *Game, player *Player, availableTokens []Attribute, slotIdx int, maxBid int) int {
+	m, ok := r.s.await(r.seat, bidDecision(slotIdx, maxBid))
+	if !ok {
+		return r.fallbackBidder().ChooseBid(g, player, availableTokens, slotIdx, maxBid)
+	}
+	return *m.Bid
+}
+
+// ChooseDiscards asks for the bees one at a time. If the player goes before
+// choosing them all, the fallback chooses the whole payment afresh.
+func (r *remoteSeat) ChooseDiscards(g *Game, player *Player, n int) []int {
+	chosen := make(map[int]bool)
+	discards := []int{}
+	for len(discards) < n {
+		m, ok := r.s.await(r.seat, discardDecision(player, chosen, n-len(discards)))
+		if !ok {
+			return r.fallbackBidder().ChooseDiscards(g, player, n)
+		}
+		chosen[*m.Card] = true
+		discards = append(discards, *m.Card)
+	}
+	return discards
+}
+
+// EndGame passes the end of the game on to the seat's stand-in
+func (r *remoteSeat) EndGame(g *Game, winner int) {
+	if ender, ok := r.s.standIn[r.seat].(GameEnder); ok {
+		ender.EndGame(g, winner)
+	}
+}
+
+// PlayerView is everything one seat may know about the game
+type PlayerView struct {
+	Game        string            `json:"game"`
+	Seat        int               `json:"seat"`
+	NumPlayers  int               `json:"num_players"`
+	Rules       RuleConfig        `json:"rules"`
+	HandNumber  int               `json:"hand_number"`
+	TrickNumber int               `json:"trick_number"`
+	Leader      int               `json:"leader"`
+	SuddenDeath bool              `json:"sudden_death"`
+	Scores      []int             `json:"scores"`
+	Board       []*AttributeToken `json:"board"`          // Slot 1 first; null for empty slots
+	Hand        []Card            `json:"hand"`           // Omitted for spectators
+	Seen        []PeekedCard      `json:"seen,omitempty"` // Opponents' bees the seat has peeked at and not seen presented
+	Revealed    []Card            `json:"revealed"`       // Cards presented so far this hand
+	LastTrick   []Play            `json:"last_trick"`
+	Decision    *Decision         `json:"decision"`    // What this seat must decide now, if anything
+	WaitingFor  []int             `json:"waiting_for"` // Remote seats with a decision open
+	Winner      int               `json:"winner"`      // -1 while the game is in progress
+}
+
+// View returns what the seat can see; a seat of -1 gives the public view
+func (s *Session) View(seat int) PlayerView {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g := s.game
+
+	view := PlayerView{
+		Game:        s.ID,
+		Seat:        seat,
+		NumPlayers:  g.NumPlayers,
+		Rules:       g.Rules,
+		HandNumber:  g.HandNumber,
+		TrickNumber: min(g.TrickNumber, g.Rules.HandSize),
+		Leader:      g.CurrentLeader,
+		SuddenDeath: g.SuddenDeath,
+		Revealed:    append([]Card{}, g.Revealed...),
+		LastTrick:   s.last,
+		WaitingFor:  []int{},
+		Winner:      s.winner,
+	}
+	view.Board = make([]*AttributeToken, len(g.Board.Slots))
+	for i, token := range g.Board.Slots {
+		if token != nil {
+			t := *token
+			view.Board[i] = &t
+		}
+	}
+	for _, p := range g.Players {
+		view.Scores = append(view.Scores, p.Score)
+	}
+	if seat >= 0 {
+		view.Hand = append([]Card{}, g.Players[seat].Hand...)
+		view.Seen = g.Players[seat].knownHeld()
+		view.Decision = s.pending[seat]
+	}
+	for i := range s.Remote {
+		if s.pending[i] != nil {
+			view.WaitingFor = append(view.WaitingFor, i)
+		}
+	}
+	return view
+}
@@ -1,59 +1,66 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/rand"
 	"os"
+	"runtime"
 	"strconv"
+	"strings"
 	"time"
 )
 
-// Attribute represents one of the 6 attribute categories
+// Attribute represents one of an edition's attribute categories
 type Attribute int
 
+// The printed edition's attributes
 const (
-	Texture Attribute = iota // Fuzzy=false, Shiny=true
-	Antennae                 // Feathered=false, Whips=true
-	Weapon                   // Stinger=false, Mandibles=true
-	Pattern                  // Striped=false, Solid=true
-	Wings                    // Sleek=false, Flutter=true
-	Payload                  // Honey=false, Pollen=true
+	Texture Attribute = iota // Fuzzy=0, Shiny=1
+	Antennae                 // Feathered=0, Whips=1
+	Weapon                   // Stinger=0, Mandibles=1
+	Pattern                  // Striped=0, Solid=1
+	Wings                    // Sleek=0, Flutter=1
+	Payload                  // Honey=0, Pollen=1
 )
 
-var attributeNames = []string{"Texture", "Antennae", "Weapon", "Pattern", "Wings", "Payload"}
-var attributeValues = [][]string{
-	{"Fuzzy", "Shiny"},
-	{"Feathered", "Whips"},
-	{"Stinger", "Mandibles"},
-	{"Striped", "Solid"},
-	{"Sleek", "Flutter"},
-	{"Honey", "Pollen"},
-}
-
-// Card represents a single card with 6 boolean attributes
+// Card represents a single card with a value for each of its edition's
+// attributes
 type Card struct {
-	Attributes [6]bool // Each index corresponds to an Attribute
+	Attributes [MaxAttributes]int // Index into each Attribute's values; unused ones are 0
+	Edition    *Edition
 }
 
-// String returns a human-readable representation of the card
+// String returns the bee's name followed by its attribute values
 func (c Card) String() string {
-	result := "["
-	for i, attr := range c.Attributes {
-		if i > 0 {
-			result += ", "
-		}
-		valueIdx := 0
-		if attr {
-			valueIdx = 1
-		}
-		result += attributeValues[i][valueIdx]
+	if name := c.Name(); name != "" {
+		return name + " " + c.Values()
 	}
-	result += "]"
-	return result
+	return c.Values()
+}
+
+// Values lists the card's attribute values in attribute order
+func (c Card) Values() string {
+	return "[" + strings.Join(c.valueNames(), ", ") + "]"
+}
+
+// MarshalJSON writes the card as its value names in attribute order
+func (c Card) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.valueNames())
+}
+
+// valueNames names the card's value for each attribute of its edition
+func (c Card) valueNames() []string {
+	edition := editionOf(c.Edition)
+	names := make([]string, edition.NumAttributes())
+	for i := range names {
+		names[i] = edition.ValueName(Attribute(i), c.Attributes[i])
+	}
+	return names
 }
 
 // Matches returns true if the card matches the given attribute value
-func (c Card) Matches(attr Attribute, value bool) bool {
+func (c Card) Matches(attr Attribute, value int) bool {
 	return c.Attributes[attr] == value
 }
 
@@ -62,26 +69,48 @@ type Player struct {
 	ID         int
 	Hand       []Card
 	TricksWon  int
+	Score      int // Points toward the target: TricksWon under the published scoring, less any paid in auctions
 	ScorePile  []Card
+	Peeked     []PeekedCard // Opponents' bees seen this hand
 }
 
-// ProtocolBoard represents the 6 slots around the Queen's Favor
+// ProtocolBoard represents the slots around the Queen's Favor, one per
+// attribute of the edition
 type ProtocolBoard struct {
-	Slots [6]*AttributeToken // Index 0 = Slot 1 (highest priority), Index 5 = Slot 6 (lowest priority)
+	Slots  []*AttributeToken // Index 0 = Slot 1 (highest priority), the last index the lowest priority
+	Locked []bool            // Slots nobody may manipulate for the rest of the Manipulate phase
+}
+
+// newBoard creates an empty board with the given number of slots
+func newBoard(numSlots int) ProtocolBoard {
+	return ProtocolBoard{Slots: make([]*AttributeToken, numSlots), Locked: make([]bool, numSlots)}
 }
 
 // AttributeToken represents a token placed on the board
 type AttributeToken struct {
 	Attribute Attribute
-	Value     bool // false or true
+	Value     int      // Index into the attribute's values
+	Edition   *Edition // Names the attribute and value
+}
+
+// MarshalJSON writes the token as its attribute and value names
+func (at AttributeToken) MarshalJSON() ([]byte, error) {
+	edition := editionOf(at.Edition)
+	return json.Marshal(map[string]string{
+		"attribute": edition.AttributeName(at.Attribute),
+		"value":     edition.ValueName(at.Attribute, at.Value),
+	})
+}
+
+// rotate turns the tile to the attribute's next value, which flips a
+// two-sided tile
+func (at *AttributeToken) rotate() {
+	at.Value = (at.Value + 1) % editionOf(at.Edition).NumValues(at.Attribute)
 }
 
 func (at AttributeToken) String() string {
-	valueIdx := 0
-	if at.Value {
-		valueIdx = 1
-	}
-	return fmt.Sprintf("%s=%s", attributeNames[at.Attribute], attributeValues[at.Attribute][valueIdx])
+	edition := editionOf(at.Edition)
+	return fmt.Sprintf("%s=%s", edition.AttributeName(at.Attribute), edition.ValueName(at.Attribute, at.Value))
 }
 
 // Game represents the entire game state
@@ -95,10 +124,37 @@ type Game struct {
 	TrickNumber        int
 	HandNumber         int
 	Verbose            bool
-	SuddenDeath        bool // True when multiple players tied at 10+
+	SuddenDeath        bool // True when multiple players tied at or above the target
 	Stats              *GameStats
 	LastTrickWinner    int
 	ConsecutiveWins    int
+	Agents             []Agent     // Per-seat decision makers; nil entries play greedily
+	DraftPicks         []DraftPick // Tokens placed during this hand's draft
+	Revealed           []Card      // Cards presented so far this hand
+	HideReasoning      bool        // Keep AI thinking out of verbose logs when humans play
+	Observers          []Observer  // Told about every public event
+	Forfeited          []bool      // Players out of contention; the game ends after the trick
+	Rules              RuleConfig  // May be changed before the game runs
+	StackedDeals       [][][]Card  // Hands to deal instead of shuffling, one entry per hand, for replays
+	Manipulations      []Manipulation // Actions made so far in this Manipulate phase
+	Pot                int            // Points a split tie left over for the next trick of the hand
+	seed               int64          // What rng was seeded with
+	rng                *rand.Rand
+	peekRNG            *rand.Rand     // Chooses the bees peeks show, apart from rng
+	edition            *Edition       // The edition the rules describe, kept for the whole game
+}
+
+// Play is a card presented by a player in the current trick
+type Play struct {
+	PlayerID int  `json:"player"`
+	Card     Card `json:"card"`
+}
+
+// DraftPick records which player placed a token in which slot
+type DraftPick struct {
+	PlayerID  int
+	SlotIndex int
+	Token     AttributeToken
 }
 
 // GameStats tracks statistics across games
@@ -108,15 +164,40 @@ type GameStats struct {
 	TricksByPlayer   []int
 	TwoStreaksByPlayer []int // Number of times each player won 2+ tricks in a row
 	ThreeStreaksByPlayer []int // Number of times each player won 3+ tricks in a row
+	ForfeitsByPlayer   []int // Games lost by crashing, timing out or moving illegally
+	TricksJudged       int   // Tricks the Judge has decided
+	SlotsJudged        int   // Slots checked before one card remained, over every trick
+	TieBreaks          int   // Tricks with cards still tied after every slot
+	PointTies          int   // Tricks the points judge found a tie for the top total in
+	Replays            int   // Extra presentations by tied players under the replay tie rule
+	SplitTricks        int   // Tricks whose points tied players shared under the split tie rule
+	HandTricks         []int // Times a player won exactly n tricks in a complete hand, by n
+	Slot1DrafterTricks int   // Tricks won by the player who drafted this hand's Slot 1
+	SlotsAuctioned     int   // Slots sold in auction drafts
+	BidsPaid           int   // Tricks or cards paid for them
+	FreeSlots          int   // Slots won with a bid of nothing
+	Slot1Paid          int   // Tricks or cards paid for Slot 1
+	TricksAfterActions int   // Tricks played after a Manipulate phase
+	LeaderRepeats      int   // Of those, tricks won again by the previous trick's winner
+	SecretActions      int   // Manipulations chosen in secret
+	CancelledActions   int   // Of those, ones an earlier manipulation ruled out
+	TrickPoints        int   // Points scored for tricks under the rules' scoring
+	HandPoints         int   // Points scored at the ends of hands for captured bees
+	WinningScores      int   // Winners' final scores, over every game
+	HandsPlayed        int   // Hands played to the end
 }
 
-// CreateDeck creates all 64 unique cards
-func CreateDeck() []Card {
-	deck := make([]Card, 64)
-	for i := 0; i < 64; i++ {
-		var card Card
-		for j := 0; j < 6; j++ {
-			card.Attributes[j] = (i & (1 << j)) != 0
+// CreateDeck creates one of each of the edition's unique cards: every
+// combination of attribute values
+func CreateDeck(edition *Edition) []Card {
+	deck := make([]Card, edition.DeckSize())
+	for i := range deck {
+		card := Card{Edition: edition}
+		rest := i
+		for j := 0; j < edition.NumAttributes(); j++ {
+			numValues := edition.NumValues(Attribute(j))
+			card.Attributes[j] = rest % numValues
+			rest /= numValues
 		}
 		deck[i] = card
 	}
@@ -124,26 +205,37 @@ func CreateDeck() []Card {
 }
 
 // ShuffleDeck shuffles the deck in place
-func ShuffleDeck(deck []Card) {
-	rand.Shuffle(len(deck), func(i, j int) {
+func ShuffleDeck(rng *rand.Rand, deck []Card) {
+	rng.Shuffle(len(deck), func(i, j int) {
 		deck[i], deck[j] = deck[j], deck[i]
 	})
 }
 
 // NewGame creates and initializes a new game
 func NewGame(numPlayers int, verbose bool) *Game {
+	return NewSeededGame(numPlayers, rand.Int63(), verbose)
+}
+
+// NewSeededGame creates a game whose deals and first leader are determined
+// by seed, so the same seed replays the same cards for deterministic agents.
+func NewSeededGame(numPlayers int, seed int64, verbose bool) *Game {
 	if numPlayers < 2 || numPlayers > 5 {
 		panic(fmt.Sprintf("Invalid number of players: %d. Must be 2-5.", numPlayers))
 	}
 
+	rng := rand.New(rand.NewSource(seed))
 	game := &Game{
 		NumPlayers:      numPlayers,
 		Players:         make([]*Player, numPlayers),
 		Verbose:         verbose,
-		CurrentLeader:   rand.Intn(numPlayers),
+		CurrentLeader:   rng.Intn(numPlayers),
 		HandNumber:      1,
+		Rules:           configuredRules,
+		Board:           newBoard(configuredRules.Edition().NumAttributes()),
 		LastTrickWinner: -1,
 		ConsecutiveWins: 0,
+		seed:            seed,
+		rng:             rng,
 	}
 
 	// Initialize players
@@ -151,13 +243,35 @@ func NewGame(numPlayers int, verbose bool) *Game {
 		game.Players[i] = &Player{ID: i}
 	}
 
-	// Create and shuffle deck
-	game.Deck = CreateDeck()
-	game.DealNewHand()
+	// Create the deck; the first hand is dealt when the game runs so that
+	// agents and observers attached after construction see its draft
+	game.Deck = game.NewDeck()
+
+	if checkInvariants {
+		game.Observers = append(game.Observers, &InvariantChecker{Seed: seed})
+	}
 
 	return game
 }
 
+// Edition returns the edition the game's rules are played with
+func (g *Game) Edition() *Edition {
+	if g.edition == nil || !g.edition.Describes(g.Rules.Attributes) {
+		g.edition = g.Rules.Edition()
+	}
+	return g.edition
+}
+
+// NewDeck creates every bee in the game: DeckCount copies of the edition's
+// unique cards
+func (g *Game) NewDeck() []Card {
+	deck := []Card{}
+	for range g.Rules.DeckCount() {
+		deck = append(deck, CreateDeck(g.Edition())...)
+	}
+	return deck
+}
+
 // NewStats creates a new statistics tracker
 func NewStats(numPlayers int) *GameStats {
 	return &GameStats{
@@ -165,6 +279,7 @@ func NewStats(numPlayers int) *GameStats {
 		TricksByPlayer:     make([]int, numPlayers),
 		TwoStreaksByPlayer: make([]int, numPlayers),
 		ThreeStreaksByPlayer: make([]int, numPlayers),
+		ForfeitsByPlayer:   make([]int, numPlayers),
 	}
 }
 
@@ -175,7 +290,7 @@ func (g *Game) DealNewHand() {
 	}
 
 	// Collect all cards
-	allCards := make([]Card, 0, 64)
+	allCards := make([]Card, 0, g.Rules.DeckSize())
 	allCards = append(allCards, g.Deck...)
 	allCards = append(allCards, g.Box...)
 	for _, player := range g.Players {
@@ -183,36 +298,66 @@ func (g *Game) DealNewHand() {
 		allCards = append(allCards, player.ScorePile...)
 		player.Hand = nil
 		player.ScorePile = nil
+		player.Peeked = nil
 	}
 
 	// Clear deck and box to prevent duplication in future hands
 	g.Deck = nil
 	g.Box = nil
 
-	// Shuffle all cards
-	ShuffleDeck(allCards)
-
-	// Deal 7 cards to each player regardless of player count
-	cardsPerPlayer := 7
+	// The rules may have changed the edition or the decks since the game
+	// was created
+	if edition := g.Edition(); len(allCards) != g.Rules.DeckSize() || allCards[0].Edition != edition {
+		allCards = g.NewDeck()
+		g.Board = newBoard(edition.NumAttributes())
+	}
 
-	// Deal cards to each player
-	cardIndex := 0
-	for i := 0; i < cardsPerPlayer; i++ {
-		for j := 0; j < g.NumPlayers; j++ {
-			g.Players[j].Hand = append(g.Players[j].Hand, allCards[cardIndex])
-			cardIndex++
+	// Shuffle all cards
+	ShuffleDeck(g.rng, allCards)
+
+	// Deal the same number of cards to each player regardless of player count
+	cardsPerPlayer := g.Rules.HandSize
+
+	if len(g.StackedDeals) > 0 {
+		// Deal the stacked hands; everything else goes to The Box
+		dealt := make(map[Card]int)
+		for j, hand := range g.StackedDeals[0] {
+			g.Players[j].Hand = append([]Card{}, hand...)
+			for _, card := range hand {
+				dealt[card]++
+			}
+		}
+		g.StackedDeals = g.StackedDeals[1:]
+		for _, card := range allCards {
+			if dealt[card] > 0 {
+				dealt[card]--
+			} else {
+				g.Box = append(g.Box, card)
+			}
+		}
+	} else {
+		// Deal cards to each player
+		cardIndex := 0
+		for i := 0; i < cardsPerPlayer; i++ {
+			for j := 0; j < g.NumPlayers; j++ {
+				g.Players[j].Hand = append(g.Players[j].Hand, allCards[cardIndex])
+				cardIndex++
+			}
 		}
-	}
 
-	// Remaining cards go to The Box
-	g.Box = allCards[cardIndex:]
+		// Remaining cards go to The Box
+		g.Box = allCards[cardIndex:]
+	}
 	g.TrickNumber = 1
+	g.DraftPicks = nil
+	g.Revealed = nil
 
 	if g.Verbose {
 		cardsPerPlayer := len(g.Players[0].Hand)
 		fmt.Printf("Dealt %d cards to each of %d players. %d cards in The Box.\n", cardsPerPlayer, g.NumPlayers, len(g.Box))
 		fmt.Printf("Leader for first trick: Player %d\n", g.CurrentLeader)
 	}
+	g.emit(Event{Kind: EventHandStart, PlayerID: g.CurrentLeader})
 
 	// Run draft phase for this hand
 	g.RunDraftPhase()
@@ -225,12 +370,11 @@ func (g *Game) RunDraftPhase() {
 	}
 
 	// Clear the board
-	for i := range g.Board.Slots {
-		g.Board.Slots[i] = nil
-	}
+	edition := g.Edition()
+	g.Board = newBoard(edition.NumAttributes())
 
-	// Available tokens (all 6 attributes)
-	availableTokens := []Attribute{Texture, Antennae, Weapon, Pattern, Wings, Payload}
+	// Available tokens (every attribute)
+	availableTokens := edition.AllAttributes()
 
 	// Build counter-clockwise order from QF holder; QF holder drafts last.
 	leader := g.CurrentLeader
@@ -240,49 +384,25 @@ func (g *Game) RunDraftPhase() {
 	}
 	ccw[g.NumPlayers-1] = leader
 
-	switch g.NumPlayers {
-	case 2:
-		// 2-player: alternate from Slot 6 down. First drafter gets 6,4,2; QF holder gets 5,3,1.
-		g.draftTokens(ccw[0], &availableTokens, []int{5})
-		g.draftTokens(ccw[1], &availableTokens, []int{4})
-		g.draftTokens(ccw[0], &availableTokens, []int{3})
-		g.draftTokens(ccw[1], &availableTokens, []int{2})
-		g.draftTokens(ccw[0], &availableTokens, []int{1})
-		g.draftTokens(ccw[1], &availableTokens, []int{0})
-
-	case 3:
-		// 3-player: first drafter gets 6,5; next gets 4,3; QF holder gets 2,1.
-		g.draftTokens(ccw[0], &availableTokens, []int{5, 4})
-		g.draftTokens(ccw[1], &availableTokens, []int{3, 2})
-		g.draftTokens(ccw[2], &availableTokens, []int{1, 0})
-
-	case 4:
-		// 4-player: first gets 6,5; next gets 4,3; next gets 2; QF holder gets 1.
-		g.draftTokens(ccw[0], &availableTokens, []int{5, 4})
-		g.draftTokens(ccw[1], &availableTokens, []int{3, 2})
-		g.draftTokens(ccw[2], &availableTokens, []int{1})
-		g.draftTokens(ccw[3], &availableTokens, []int{0})
-
-	case 5:
-		// 5-player: first gets 6,5; remaining each get one slot (4,3,2,1).
-		g.draftTokens(ccw[0], &availableTokens, []int{5, 4})
-		g.draftTokens(ccw[1], &availableTokens, []int{3})
-		g.draftTokens(ccw[2], &availableTokens, []int{2})
-		g.draftTokens(ccw[3], &availableTokens, []int{1})
-		g.draftTokens(ccw[4], &availableTokens, []int{0})
+	if g.Rules.Auction != "" {
+		g.RunAuctionPhase(ccw, &availableTokens)
+	} else {
+		for _, turn := range g.Rules.DraftTurns(g.NumPlayers) {
+			g.draftTokens(ccw[turn.Drafter], &availableTokens, turn.Slots)
+		}
 	}
 
 	if g.Verbose {
 		fmt.Println("\nQueen's Favor:")
-		for i := 0; i < 6; i++ {
-			if g.Board.Slots[i] != nil {
-				fmt.Printf("  Slot %d: %s\n", i+1, g.Board.Slots[i])
+		for i, token := range g.Board.Slots {
+			if token != nil {
+				fmt.Printf("  Slot %d: %s\n", i+1, token)
 			}
 		}
 	}
 }
 
-// draftTokens simulates a player drafting tokens (simple AI)
+// draftTokens has a player's agent choose a token for each of the given slots
 func (g *Game) draftTokens(playerID int, availableTokens *[]Attribute, slots []int) {
 	player := g.Players[playerID]
 
@@ -291,14 +411,20 @@ func (g *Game) draftTokens(playerID int, availableTokens *[]Attribute, slots []i
 			break
 		}
 
-		// Simple AI: Analyze hand for best token to place
-		attr, value := g.selectBestToken(player, *availableTokens, slotIdx)
+		attr, value := g.agent(playerID).ChooseToken(g, player, *availableTokens, slotIdx)
 
 		// Place the token
 		g.Board.Slots[slotIdx] = &AttributeToken{
 			Attribute: attr,
 			Value:     value,
+			Edition:   g.Edition(),
 		}
+		g.DraftPicks = append(g.DraftPicks, DraftPick{
+			PlayerID:  playerID,
+			SlotIndex: slotIdx,
+			Token:     *g.Board.Slots[slotIdx],
+		})
+		g.emit(Event{Kind: EventDraft, PlayerID: playerID, SlotIndex: slotIdx, Token: g.Board.Slots[slotIdx]})
 
 		// Remove from available tokens
 		for i, a := range *availableTokens {
@@ -315,45 +441,34 @@ func (g *Game) draftTokens(playerID int, availableTokens *[]Attribute, slots []i
 }
 
 // selectBestToken uses simple AI to select the best token to place
-func (g *Game) selectBestToken(player *Player, availableTokens []Attribute, slotIdx int) (Attribute, bool) {
-	// Count how many cards match each attribute value
+func (g *Game) selectBestToken(player *Player, availableTokens []Attribute, slotIdx int) (Attribute, int) {
+	return g.selectTokenFor(player, availableTokens, slotIdx, 1)
+}
+
+// selectTokenFor backs the value the player holds most of in the first
+// majoritySlots slots, and buries its weakest value in the rest
+func (g *Game) selectTokenFor(player *Player, availableTokens []Attribute, slotIdx int, majoritySlots int) (Attribute, int) {
+	edition := g.Edition()
 	bestAttr := availableTokens[0]
-	bestValue := false
+	bestValue := 0
 	bestScore := -1
 
 	for _, attr := range availableTokens {
-		trueCount := 0
-		falseCount := 0
+		// Count how many cards match each attribute value
+		counts := valueCounts(player.Hand, attr, edition.NumValues(attr))
+		most, fewest := extremeValues(counts)
 
-		for _, card := range player.Hand {
-			if card.Attributes[attr] {
-				trueCount++
-			} else {
-				falseCount++
-			}
-		}
-
-		// For Slot 1 (index 0), prefer attribute values we have more of
+		// For Slot 1 (the first majoritySlots slots), prefer attribute values we have more of
 		// For other slots, prefer attribute values we have fewer of (to bury weakness)
 		var score int
-		var value bool
+		var value int
 
-		if slotIdx == 0 { // Slot 1 - most important
-			if trueCount > falseCount {
-				score = trueCount
-				value = true
-			} else {
-				score = falseCount
-				value = false
-			}
+		if slotIdx < majoritySlots { // Slot 1 - most important
+			score = counts[most]
+			value = most
 		} else { // Less important slots - bury weaknesses
-			if trueCount < falseCount {
-				score = 7 - falseCount // Prefer to bury our stronger side
-				value = true           // Place the value we have less of
-			} else {
-				score = 7 - trueCount
-				value = false
-			}
+			score = len(player.Hand) - counts[most] // Prefer to bury our stronger side
+			value = fewest                          // Place the value we have least of
 		}
 
 		if score > bestScore {
@@ -366,41 +481,88 @@ func (g *Game) selectBestToken(player *Player, availableTokens []Attribute, slot
 	return bestAttr, bestValue
 }
 
+// extremeValues returns the values with the highest and lowest counts,
+// preferring the lowest value on ties
+func extremeValues(counts []int) (most, fewest int) {
+	for v, count := range counts {
+		if count > counts[most] {
+			most = v
+		}
+		if count < counts[fewest] {
+			fewest = v
+		}
+	}
+	return most, fewest
+}
+
+// valueCounts counts the cards holding each of an attribute's values
+func valueCounts(hand []Card, attr Attribute, numValues int) []int {
+	counts := make([]int, numValues)
+	for _, card := range hand {
+		counts[card.Attributes[attr]]++
+	}
+	return counts
+}
+
 // RunPlayPhase executes the reveal phase where each player plays a card
-func (g *Game) RunPlayPhase() []struct {
-	PlayerID int
-	Card     Card
-} {
+func (g *Game) RunPlayPhase() []Play {
 	if g.Verbose {
 		fmt.Printf("\n--- Trick %d: Reveal Phase ---\n", g.TrickNumber)
 	}
 
-	plays := make([]struct {
-		PlayerID int
-		Card     Card
-	}, g.NumPlayers)
+	plays := make([]Play, g.NumPlayers)
 
 	// Each player selects a card
 	for i := 0; i < g.NumPlayers; i++ {
 		playerIdx := (g.CurrentLeader + i) % g.NumPlayers
 		player := g.Players[playerIdx]
 
-		// Simple AI: select best card based on board
-		cardIdx := g.selectBestCard(player)
+		cardIdx := g.agent(playerIdx).ChooseCard(g, player)
 		card := player.Hand[cardIdx]
 
 		// Remove card from hand
 		player.Hand = append(player.Hand[:cardIdx], player.Hand[cardIdx+1:]...)
 
-		plays[i] = struct {
-			PlayerID int
-			Card     Card
-		}{playerIdx, card}
+		plays[i] = Play{PlayerID: playerIdx, Card: card}
+	}
 
+	// Cards are presented simultaneously, so reveal them once everyone has chosen
+	for _, play := range plays {
+		g.Revealed = append(g.Revealed, play.Card)
+		g.notePresented(play)
 		if g.Verbose {
-			fmt.Printf("Player %d plays: %s\n", playerIdx, card)
+			fmt.Printf("Player %d plays: %s\n", play.PlayerID, play.Card)
 		}
 	}
+	g.emit(Event{Kind: EventReveal, Plays: plays})
+
+	return plays
+}
+
+// RunReplayPhase has the tied players present another bee each, in play
+// order, under the replay tie rule
+func (g *Game) RunReplayPhase(playerIDs []int) []Play {
+	if g.Verbose {
+		fmt.Printf("\n--- Trick %d: Replay (Players %s tied) ---\n", g.TrickNumber, joinInts(playerIDs))
+	}
+
+	plays := make([]Play, len(playerIDs))
+	for i, playerIdx := range playerIDs {
+		player := g.Players[playerIdx]
+		cardIdx := g.agent(playerIdx).ChooseCard(g, player)
+		card := player.Hand[cardIdx]
+		player.Hand = append(player.Hand[:cardIdx], player.Hand[cardIdx+1:]...)
+		plays[i] = Play{PlayerID: playerIdx, Card: card}
+	}
+
+	for _, play := range plays {
+		g.Revealed = append(g.Revealed, play.Card)
+		g.notePresented(play)
+		if g.Verbose {
+			fmt.Printf("Player %d plays: %s\n", play.PlayerID, play.Card)
+		}
+	}
+	g.emit(Event{Kind: EventReplay, Plays: plays})
 
 	return plays
 }
@@ -424,45 +586,177 @@ func (g *Game) selectBestCard(player *Player) int {
 
 // scoreCard scores how well a card matches the protocol board
 func (g *Game) scoreCard(card Card) int {
+	if g.Rules.JudgeName() == JudgePoints {
+		// The total decides; the published order only settles equal totals
+		score := g.cardPoints(card) * (g.Edition().MaxScore() + 1)
+		if g.Rules.TieBreakName() == TieBreakSlots {
+			score += g.slotScore(card)
+		}
+		return score
+	}
+	return g.slotScore(card)
+}
+
+// slotScore scores a card so that the published Judge prefers the higher
+func (g *Game) slotScore(card Card) int {
 	score := 0
-	for i := 0; i < 6; i++ {
-		if g.Board.Slots[i] != nil {
-			token := g.Board.Slots[i]
-			if card.Matches(token.Attribute, token.Value) {
-				// Use powers of 2 so earlier slots always dominate
-				// Slot 1 (i=0) = 32, Slot 2 = 16, Slot 3 = 8, Slot 4 = 4, Slot 5 = 2, Slot 6 = 1
-				score += 1 << (5 - i)
-			}
+	last := len(g.Board.Slots) - 1
+	for i, token := range g.Board.Slots {
+		if token != nil && card.Matches(token.Attribute, token.Value) {
+			// Use powers of 2 so earlier slots always dominate
+			// With six slots: Slot 1 (i=0) = 32, Slot 2 = 16, Slot 3 = 8, Slot 4 = 4, Slot 5 = 2, Slot 6 = 1
+			score += 1 << (last - i)
 		}
 	}
 	return score
 }
 
 // RunFilterPhase executes the judgement phase to determine the winner
-func (g *Game) RunFilterPhase(plays []struct {
-	PlayerID int
-	Card     Card
-}) int {
+func (g *Game) RunFilterPhase(plays []Play) int {
 	if g.Verbose {
 		fmt.Printf("\n--- Trick %d: Judgement Phase ---\n", g.TrickNumber)
 	}
 
+	active, judged, pointTie := g.judgeTrick(plays)
+	tied := len(active) > 1
+
+	// Under the replay tie rule, the tied players present again and the
+	// Judge compares only the new bees; the winner takes them all
+	trick := append([]Play{}, plays...)
+	for len(active) > 1 && g.Rules.TiesName() == TiesReplay && g.canReplay(plays, active) {
+		plays = g.RunReplayPhase(playerIDs(plays, active))
+		trick = append(trick, plays...)
+		active, judged, _ = g.judgeTrick(plays)
+		if g.Stats != nil {
+			g.Stats.Replays++
+		}
+	}
+
+	// Determine winner
+	var winnerIdx int
+	if len(active) == 1 {
+		winnerIdx = active[0]
+	} else {
+		winnerIdx = g.breakTie(plays, active)
+	}
+
+	winnerPlayerID := plays[winnerIdx].PlayerID
+
+	points := g.scoring().TrickPoints(g, winnerPlayerID, judged, len(trick)) + g.Pot
+	g.Pot = 0
+	var shares []int
+	if len(active) > 1 && g.Rules.TiesName() == TiesSplit {
+		shares = g.splitPoints(plays, active, points, winnerPlayerID)
+	}
+	if g.Verbose && shares != nil {
+		fmt.Printf("Player %d takes the bees and the lead.\n", winnerPlayerID)
+	} else if g.Verbose {
+		fmt.Printf("\nPlayer %d wins the trick!\n", winnerPlayerID)
+	}
+	g.emit(Event{Kind: EventTrickWon, PlayerID: winnerPlayerID, Plays: trick, Points: points, Shares: shares})
+
+	// Award the trick. Nobody wins a split trick: its points are shared,
+	// and the player the tie-break favours only takes the cards and leads.
+	if shares == nil {
+		g.Players[winnerPlayerID].TricksWon++
+	}
+	for _, p := range g.Players {
+		p.Score += pointShare(shares, points, winnerPlayerID, p.ID)
+	}
+	for _, play := range trick {
+		g.Players[winnerPlayerID].ScorePile = append(g.Players[winnerPlayerID].ScorePile, play.Card)
+	}
+
+	// Track statistics
+	if g.Stats != nil {
+		g.Stats.TrickPoints += points - g.Pot
+		g.Stats.TricksJudged++
+		g.Stats.SlotsJudged += judged
+		if pointTie {
+			g.Stats.PointTies++
+		}
+		if tied {
+			g.Stats.TieBreaks++
+		}
+		if shares != nil {
+			g.Stats.SplitTricks++
+		} else {
+			g.Stats.TricksByPlayer[winnerPlayerID]++
+			for _, pick := range g.DraftPicks {
+				if pick.SlotIndex == 0 && pick.PlayerID == winnerPlayerID {
+					g.Stats.Slot1DrafterTricks++
+				}
+			}
+		}
+		if g.TrickNumber > 1 {
+			// The leader won the previous trick, or took it on a split
+			g.Stats.TricksAfterActions++
+			if shares == nil && winnerPlayerID == g.CurrentLeader {
+				g.Stats.LeaderRepeats++
+			}
+		}
+
+		// Track consecutive wins, which a split trick ends
+		if shares != nil {
+			g.ConsecutiveWins = 0
+			g.LastTrickWinner = -1
+		} else if g.LastTrickWinner == winnerPlayerID {
+			g.ConsecutiveWins++
+			if g.ConsecutiveWins == 2 {
+				g.Stats.TwoStreaksByPlayer[winnerPlayerID]++
+			}
+			if g.ConsecutiveWins == 3 {
+				g.Stats.ThreeStreaksByPlayer[winnerPlayerID]++
+			}
+		} else {
+			g.ConsecutiveWins = 1
+			g.LastTrickWinner = winnerPlayerID
+		}
+	}
+
+	return winnerPlayerID
+}
+
+// judgeTrick has the Judge compare the plays. It returns the cards left,
+// the number of slots judged, and whether the points judge found a tie for
+// the top total.
+func (g *Game) judgeTrick(plays []Play) ([]int, int, bool) {
 	// Start with all players active
 	active := make([]int, len(plays))
 	for i := range plays {
 		active[i] = i
 	}
 
+	// The published Judge goes slot by slot; the points judge totals each
+	// card first, then settles equal totals as the rules say
+	if g.Rules.JudgeName() == JudgePoints {
+		active = g.judgePoints(plays, active)
+		pointTie := len(active) > 1
+		if pointTie && g.Rules.TieBreakName() == TieBreakSlots {
+			active, _ = g.judgeSlots(plays, active)
+		}
+		return active, len(g.Board.Slots), pointTie
+	}
+	active, judged := g.judgeSlots(plays, active)
+	return active, judged, false
+}
+
+// judgeSlots checks the active cards against each slot from Slot 1,
+// eliminating those that miss a slot some other card matches, until one
+// card remains or every slot has been judged. It returns the cards left and
+// the number of slots judged.
+func (g *Game) judgeSlots(plays []Play, active []int) ([]int, int) {
 	// Process each slot starting from Slot 1
-	for slotIdx := 0; slotIdx < 6; slotIdx++ {
+	judged := 0
+	for slotIdx, token := range g.Board.Slots {
 		if len(active) == 1 {
 			break // Only one card remains
 		}
 
-		token := g.Board.Slots[slotIdx]
 		if token == nil {
 			continue
 		}
+		judged++
 
 		if g.Verbose {
 			fmt.Printf("\nChecking Slot %d: %s\n", slotIdx+1, token)
@@ -481,6 +775,7 @@ func (g *Game) RunFilterPhase(plays []struct {
 			if g.Verbose {
 				fmt.Printf("  No cards match Slot %d. Proceeding to next slot.\n", slotIdx+1)
 			}
+			g.emit(Event{Kind: EventJudgeSlot, SlotIndex: slotIdx, Token: token, Survivors: playerIDs(plays, active)})
 			continue
 		}
 
@@ -507,130 +802,110 @@ func (g *Game) RunFilterPhase(plays []struct {
 			}
 
 			active = matching
+			g.emit(Event{
+				Kind:       EventJudgeSlot,
+				SlotIndex:  slotIdx,
+				Token:      token,
+				Eliminated: playerIDs(plays, eliminated),
+				Survivors:  playerIDs(plays, active),
+			})
 		}
 	}
+	return active, judged
+}
 
-	// Determine winner
-	var winnerIdx int
-	if len(active) == 1 {
-		winnerIdx = active[0]
-	} else {
-		// Tie-breaker: closest to leader in play order
-		winnerIdx = active[0]
-		if g.Verbose {
-			fmt.Printf("\nTie-breaker: Multiple cards survived. Winner is closest to leader.\n")
-		}
-	}
-
-	winnerPlayerID := plays[winnerIdx].PlayerID
-
+// RunActionPhase executes the action phase after each trick
+func (g *Game) RunActionPhase() {
 	if g.Verbose {
-		fmt.Printf("\nPlayer %d wins the trick!\n", winnerPlayerID)
+		fmt.Printf("\n--- Trick %d: Action Phase ---\n", g.TrickNumber)
+		fmt.Println("Current Queen's Favor:")
+		for i, token := range g.Board.Slots {
+			if token != nil {
+				fmt.Printf("  Slot %d: %s\n", i+1, token)
+			}
+		}
+		fmt.Println()
 	}
 
-	// Award the trick
-	g.Players[winnerPlayerID].TricksWon++
-	for _, play := range plays {
-		g.Players[winnerPlayerID].ScorePile = append(g.Players[winnerPlayerID].ScorePile, play.Card)
+	// Each player takes one action, starting with the leader unless the
+	// rules give another order. Every action this round is kept for the
+	// no-repeat rules.
+	g.Manipulations = nil
+	order := g.actionOrder()
+	if g.Rules.ActionOrderRule() == ActionOrderSecret {
+		g.runSecretActions(order)
+	} else {
+		g.runActionTurns(order)
 	}
 
-	// Track statistics
-	if g.Stats != nil {
-		g.Stats.TricksByPlayer[winnerPlayerID]++
+	// Locks and the no-repeat rules last only for the round
+	clear(g.Board.Locked)
+	g.Manipulations = nil
 
-		// Track consecutive wins
-		if g.LastTrickWinner == winnerPlayerID {
-			g.ConsecutiveWins++
-			if g.ConsecutiveWins == 2 {
-				g.Stats.TwoStreaksByPlayer[winnerPlayerID]++
-			}
-			if g.ConsecutiveWins == 3 {
-				g.Stats.ThreeStreaksByPlayer[winnerPlayerID]++
+	if g.Verbose {
+		fmt.Println("\nQueen's Favor after actions:")
+		for i, token := range g.Board.Slots {
+			if token != nil {
+				fmt.Printf("  Slot %d: %s\n", i+1, token)
 			}
-		} else {
-			g.ConsecutiveWins = 1
-			g.LastTrickWinner = winnerPlayerID
 		}
 	}
-
-	return winnerPlayerID
 }
 
-// RunActionPhase executes the action phase after each trick
-func (g *Game) RunActionPhase() {
-	if g.Verbose {
-		fmt.Printf("\n--- Trick %d: Action Phase ---\n", g.TrickNumber)
-		fmt.Println("Current Queen's Favor:")
-		for i := 0; i < 6; i++ {
-			if g.Board.Slots[i] != nil {
-				fmt.Printf("  Slot %d: %s\n", i+1, g.Board.Slots[i])
-			}
+// clone returns a copy of the board that shares no tokens with the original
+func (b ProtocolBoard) clone() ProtocolBoard {
+	c := newBoard(len(b.Slots))
+	for i, token := range b.Slots {
+		if token != nil {
+			t := *token
+			c.Slots[i] = &t
 		}
-		fmt.Println()
 	}
+	copy(c.Locked, b.Locked)
+	return c
+}
 
+// runActionTurns has each player in order take an action on the board the
+// players before them left
+func (g *Game) runActionTurns(order []int) {
 	// Track the previous action to prevent immediate repeats
 	var previousAction *Action
 
-	// Each player takes one action, starting with the leader
-	for i := 0; i < g.NumPlayers; i++ {
-		playerIdx := (g.CurrentLeader + i) % g.NumPlayers
+	for _, playerIdx := range order {
 		player := g.Players[playerIdx]
 
-		// AI decides which action to take (excluding previous action)
-		action := g.selectBestAction(player, previousAction)
-
-		switch action.Type {
-		case "flip":
-			slot := action.SlotIndex
-			g.Board.Slots[slot].Value = !g.Board.Slots[slot].Value
-			if g.Verbose {
-				fmt.Printf("Player %d flips Slot %d to %s\n", playerIdx, slot+1, g.Board.Slots[slot])
-			}
-
-		case "swap":
-			slot1, slot2 := action.SlotIndex, action.SlotIndex2
-			g.Board.Slots[slot1], g.Board.Slots[slot2] = g.Board.Slots[slot2], g.Board.Slots[slot1]
+		// Some rules can leave nothing legal, such as pass alone after a
+		// pass; the player then sits the round out
+		if len(g.legalActions(player, previousAction)) == 0 {
 			if g.Verbose {
-				fmt.Printf("Player %d swaps Slot %d and Slot %d\n", playerIdx, slot1+1, slot2+1)
+				fmt.Printf("Player %d has no manipulation left\n", playerIdx)
 			}
+			continue
 		}
 
+		// Agent decides which action to take (excluding previous action)
+		action := g.agent(playerIdx).ChooseAction(g, player, previousAction)
+		g.makeAction(player, action)
+
 		// Save this action to prevent the next player from repeating it
 		previousAction = &action
 	}
-
-	if g.Verbose {
-		fmt.Println("\nQueen's Favor after actions:")
-		for i := 0; i < 6; i++ {
-			if g.Board.Slots[i] != nil {
-				fmt.Printf("  Slot %d: %s\n", i+1, g.Board.Slots[i])
-			}
-		}
-	}
 }
 
-// Action represents a player's action choice
-type Action struct {
-	Type       string // "flip" or "swap"
-	SlotIndex  int    // For flip: the slot to flip. For swap: first slot
-	SlotIndex2 int    // For swap: second slot
-}
-
-// actionsMatch returns true if two actions are the same
-func actionsMatch(a1, a2 Action) bool {
-	if a1.Type != a2.Type {
-		return false
+// makeAction performs a player's manipulation and tells everyone about it
+func (g *Game) makeAction(player *Player, action Action) {
+	before := g.Board.clone()
+	kind := actionKinds[action.Type]
+	kind.Apply(&g.Board, action)
+	if r, ok := kind.(Resolver); ok {
+		r.Resolve(g, player, action)
 	}
-	switch a1.Type {
-	case "flip":
-		return a1.SlotIndex == a2.SlotIndex
-	case "swap":
-		// Swaps match if they involve the same two slots (in either order)
-		return (a1.SlotIndex == a2.SlotIndex && a1.SlotIndex2 == a2.SlotIndex2) ||
-			(a1.SlotIndex == a2.SlotIndex2 && a1.SlotIndex2 == a2.SlotIndex)
+	if g.Verbose {
+		fmt.Printf("Player %d %s\n", player.ID, kind.Narrate(g.Board, action))
 	}
-	return false
+
+	g.emit(Event{Kind: EventAction, PlayerID: player.ID, Action: &action})
+	g.Manipulations = append(g.Manipulations, Manipulation{PlayerID: player.ID, Action: action, Before: before, After: g.Board.clone()})
 }
 
 // selectBestAction uses AI to select the best action for a player
@@ -647,19 +922,12 @@ func (g *Game) selectBestAction(player *Player, previousAction *Action) Action {
 	}
 
 	// Evaluate all legal actions and pick the best
-	bestAction := Action{Type: "flip", SlotIndex: 0}
+	actions := g.legalActions(player, previousAction)
+	bestAction := actions[0]
 	bestScore := -1
 	bestCard := -1
 
-	// Try all flip actions
-	for slot := 0; slot < 6; slot++ {
-		action := Action{Type: "flip", SlotIndex: slot}
-
-		// Skip if this matches the previous action
-		if previousAction != nil && actionsMatch(action, *previousAction) {
-			continue
-		}
-
+	for _, action := range actions {
 		score, cardIdx := g.scoreActionOutcome(player, action)
 		if score > bestScore {
 			bestScore = score
@@ -668,33 +936,8 @@ func (g *Game) selectBestAction(player *Player, previousAction *Action) Action {
 		}
 	}
 
-	// Try all swap actions
-	for slot1 := 0; slot1 < 6; slot1++ {
-		for slot2 := slot1 + 1; slot2 < 6; slot2++ {
-			action := Action{Type: "swap", SlotIndex: slot1, SlotIndex2: slot2}
-
-			// Skip if this matches the previous action
-			if previousAction != nil && actionsMatch(action, *previousAction) {
-				continue
-			}
-
-			score, cardIdx := g.scoreActionOutcome(player, action)
-			if score > bestScore {
-				bestScore = score
-				bestCard = cardIdx
-				bestAction = action
-			}
-		}
-	}
-
 	// Log the decision
-	if g.Verbose {
-		actionType := bestAction.Type
-		if actionType == "flip" {
-			actionType = fmt.Sprintf("flip slot %d", bestAction.SlotIndex+1)
-		} else if actionType == "swap" {
-			actionType = fmt.Sprintf("swap slots %d+%d", bestAction.SlotIndex+1, bestAction.SlotIndex2+1)
-		}
+	if g.showReasoning() {
 		cardChange := ""
 		if bestCard != currentBestCard {
 			cardChange = fmt.Sprintf(" (changes best card from #%d to #%d)", currentBestCard, bestCard)
@@ -702,15 +945,11 @@ func (g *Game) selectBestAction(player *Player, previousAction *Action) Action {
 
 		blocked := ""
 		if previousAction != nil {
-			if previousAction.Type == "flip" {
-				blocked = fmt.Sprintf(" [blocked: flip slot %d]", previousAction.SlotIndex+1)
-			} else if previousAction.Type == "swap" {
-				blocked = fmt.Sprintf(" [blocked: swap %d+%d]", previousAction.SlotIndex+1, previousAction.SlotIndex2+1)
-			}
+			blocked = fmt.Sprintf(" [blocked: %s]", previousAction)
 		}
 
 		fmt.Printf("  (Player %d AI: best=%d, choosing %s%s%s)\n",
-			player.ID, bestScore, actionType, cardChange, blocked)
+			player.ID, bestScore, bestAction, cardChange, blocked)
 	}
 
 	return bestAction
@@ -718,25 +957,10 @@ func (g *Game) selectBestAction(player *Player, previousAction *Action) Action {
 
 // scoreActionOutcome simulates an action and scores how good it is for the player
 func (g *Game) scoreActionOutcome(player *Player, action Action) (int, int) {
-	// Save current board state
-	savedBoard := make([]*AttributeToken, 6)
-	for i := 0; i < 6; i++ {
-		if g.Board.Slots[i] != nil {
-			savedBoard[i] = &AttributeToken{
-				Attribute: g.Board.Slots[i].Attribute,
-				Value:     g.Board.Slots[i].Value,
-			}
-		}
-	}
-
-	// Apply the action temporarily
-	switch action.Type {
-	case "flip":
-		g.Board.Slots[action.SlotIndex].Value = !g.Board.Slots[action.SlotIndex].Value
-
-	case "swap":
-		g.Board.Slots[action.SlotIndex], g.Board.Slots[action.SlotIndex2] = g.Board.Slots[action.SlotIndex2], g.Board.Slots[action.SlotIndex]
-	}
+	// Apply the action temporarily to a copy of the board
+	savedBoard := g.Board
+	g.Board = savedBoard.clone()
+	g.Board.apply(action)
 
 	// Find the best card score with the new board
 	bestScore := -1
@@ -750,9 +974,7 @@ func (g *Game) scoreActionOutcome(player *Player, action Action) (int, int) {
 	}
 
 	// Restore board state
-	for i := 0; i < 6; i++ {
-		g.Board.Slots[i] = savedBoard[i]
-	}
+	g.Board = savedBoard
 
 	return bestScore, bestCardIdx
 }
@@ -782,8 +1004,9 @@ func (g *Game) PlayTrick(isLastTrick bool) int {
 	// starts with the player that won this round, proceeding clockwise)
 	g.CurrentLeader = winner
 
-	// Skip Manipulate phase on the final round of a hand
-	if !isLastTrick {
+	// Skip Manipulate phase on the final round of a hand, including when
+	// replaying a tie used up someone's last bee
+	if !isLastTrick && g.minHandSize() > 0 {
 		g.RunActionPhase()
 	} else if g.Verbose {
 		fmt.Println("\n(Skipping Manipulate phase — final round of hand)")
@@ -793,28 +1016,71 @@ func (g *Game) PlayTrick(isLastTrick bool) int {
 	return -1
 }
 
+// minHandSize is the number of bees in the shortest hand
+func (g *Game) minHandSize() int {
+	size := len(g.Players[0].Hand)
+	for _, p := range g.Players {
+		size = min(size, len(p.Hand))
+	}
+	return size
+}
+
 // PlayHand plays all tricks in a hand, returns true if game ended during hand
 func (g *Game) PlayHand() bool {
-	tricksPerHand := len(g.Players[0].Hand)
-	for i := 0; i < tricksPerHand; i++ {
-		isLastTrick := (i == tricksPerHand-1)
+	tricksBefore := make([]int, g.NumPlayers)
+	for i, p := range g.Players {
+		tricksBefore[i] = p.TricksWon
+	}
+
+	// Bees paid in an auction draft or presented again to settle a tie
+	// shorten the hand for everyone
+	for g.minHandSize() > 0 {
+		isLastTrick := g.minHandSize() == 1
 		sdWinner := g.PlayTrick(isLastTrick)
 
+		// A forfeit ends the game once the trick in progress is over
+		if g.hasForfeit() {
+			return true
+		}
+
 		// During sudden death, PlayTrick checks for a winner after the
 		// Judge phase (before Manipulation) and returns the winner ID.
 		if sdWinner != -1 {
 			if g.Verbose {
-				fmt.Printf("\n🎉 SUDDEN DEATH WINNER! Player %d breaks ahead with %d tricks! 🎉\n", sdWinner, g.Players[sdWinner].TricksWon)
+				fmt.Printf("\n🎉 SUDDEN DEATH WINNER! Player %d breaks ahead with %s! 🎉\n", sdWinner, g.scoreLabel(g.Players[sdWinner].Score))
 				g.PrintScores()
 			}
 			return true // Game ended
 		}
 	}
 
+	// Score the captured bees
+	for _, p := range g.Players {
+		points := g.scoring().HandPoints(g, p)
+		p.Score += points
+		if g.Stats != nil {
+			g.Stats.HandPoints += points
+		}
+		if g.Verbose && points > 0 {
+			fmt.Printf("Player %d scores %s for their captured bees\n", p.ID, g.scoreLabel(points))
+		}
+	}
+
 	if g.Verbose {
 		fmt.Printf("\n=== End of Hand %d ===\n", g.HandNumber)
 		g.PrintScores()
 	}
+	if g.Stats != nil {
+		g.Stats.HandsPlayed++
+		for i, p := range g.Players {
+			tricks := p.TricksWon - tricksBefore[i]
+			for len(g.Stats.HandTricks) <= tricks {
+				g.Stats.HandTricks = append(g.Stats.HandTricks, 0)
+			}
+			g.Stats.HandTricks[tricks]++
+		}
+	}
+	g.emit(Event{Kind: EventHandEnd})
 
 	g.HandNumber++
 	return false // Hand completed, game continues
@@ -822,23 +1088,23 @@ func (g *Game) PlayHand() bool {
 
 // CheckWinner returns the player ID if someone has won, otherwise -1
 func (g *Game) CheckWinner() int {
-	// Check if at least one player has 10+ tricks
-	maxTricks := 0
+	// Check if at least one player has reached the target
+	maxScore := 0
 	for _, player := range g.Players {
-		if player.TricksWon > maxTricks {
-			maxTricks = player.TricksWon
+		if player.Score > maxScore {
+			maxScore = player.Score
 		}
 	}
 
-	// If no one has 10+ tricks yet, continue playing
-	if maxTricks < 10 {
+	// If no one has reached the target yet, continue playing
+	if maxScore < g.Rules.Target(g.NumPlayers) {
 		return -1
 	}
 
-	// Find all players with the maximum number of tricks
+	// Find all players with the maximum score
 	playersWithMax := []int{}
 	for _, player := range g.Players {
-		if player.TricksWon == maxTricks {
+		if player.Score == maxScore {
 			playersWithMax = append(playersWithMax, player.ID)
 		}
 	}
@@ -854,12 +1120,12 @@ func (g *Game) CheckWinner() int {
 
 // DetermineNextLeader sets the leader for the next hand
 func (g *Game) DetermineNextLeader() {
-	maxTricks := -1
+	maxScore := -1
 	leaderID := 0
 
 	for _, player := range g.Players {
-		if player.TricksWon > maxTricks {
-			maxTricks = player.TricksWon
+		if player.Score > maxScore {
+			maxScore = player.Score
 			leaderID = player.ID
 		}
 	}
@@ -867,45 +1133,134 @@ func (g *Game) DetermineNextLeader() {
 	g.CurrentLeader = leaderID
 }
 
+// showReasoning reports whether AI agents should explain their decisions
+func (g *Game) showReasoning() bool {
+	return g.Verbose && !g.HideReasoning
+}
+
 // PrintScores prints the current score
 func (g *Game) PrintScores() {
 	fmt.Println("\nCurrent Scores:")
 	for _, player := range g.Players {
-		fmt.Printf("  Player %d: %d tricks\n", player.ID, player.TricksWon)
+		fmt.Printf("  Player %d: %s\n", player.ID, g.scoreLabel(player.Score))
 	}
 }
 
+// Forfeit takes a player out of contention, for example a bot that crashed
+// or moved illegally. The trick in progress is completed, then the game ends
+// and the best of the remaining players wins.
+func (g *Game) Forfeit(playerID int, reason string) {
+	if g.Forfeited == nil {
+		g.Forfeited = make([]bool, g.NumPlayers)
+	}
+	if g.Forfeited[playerID] {
+		return
+	}
+	g.Forfeited[playerID] = true
+	if g.Stats != nil {
+		g.Stats.ForfeitsByPlayer[playerID]++
+	}
+	if g.Verbose {
+		fmt.Printf("\nPlayer %d forfeits: %s\n", playerID, reason)
+	}
+}
+
+// forfeited reports whether a player has forfeited
+func (g *Game) forfeited(playerID int) bool {
+	return g.Forfeited != nil && g.Forfeited[playerID]
+}
+
+// hasForfeit reports whether anyone has forfeited
+func (g *Game) hasForfeit() bool {
+	for _, f := range g.Forfeited {
+		if f {
+			return true
+		}
+	}
+	return false
+}
+
+// allForfeited reports whether nobody is left in contention
+func (g *Game) allForfeited() bool {
+	for id := range g.Players {
+		if !g.forfeited(id) {
+			return false
+		}
+	}
+	return true
+}
+
+// finish records the winner and tells observers and agents the game is over
+func (g *Game) finish(winner int) int {
+	if g.Stats != nil {
+		g.Stats.WinsByPlayer[winner]++
+		g.Stats.WinningScores += g.Players[winner].Score
+	}
+	g.emit(Event{Kind: EventGameOver, PlayerID: winner})
+	for _, agent := range g.Agents {
+		if ender, ok := agent.(GameEnder); ok {
+			ender.EndGame(g, winner)
+		}
+	}
+	return winner
+}
+
 // Run executes the complete game simulation
 func (g *Game) Run() int {
 	if g.Verbose {
 		fmt.Println("=== EYE OF THE BEE-HOLDER SIMULATION ===")
-		fmt.Println("First player to 10 tricks wins!\n")
+		fmt.Printf("First player to %s wins!\n", g.scoreLabel(g.Rules.Target(g.NumPlayers)))
+		if scoring := g.Rules.ScoringName(); scoring != "tricks" {
+			fmt.Printf("Scoring: %s.\n", scoring)
+		}
+		if g.Rules.JudgeName() == JudgePoints {
+			fmt.Printf("Judge: points, weights %v, ties by %s.\n", g.Rules.Weights(len(g.Board.Slots)), g.Rules.TieBreakName())
+		}
+		if edition := g.Edition(); edition != printedEdition {
+			fmt.Printf("Playing an edition with %d attributes and %d bees.\n", edition.NumAttributes(), edition.DeckSize())
+		}
+		if decks := g.Rules.DeckCount(); decks > 1 {
+			fmt.Printf("Playing with %d copies of every bee.\n", decks)
+		}
+		if ties := g.Rules.TiesName(); ties != TiesClosest {
+			fmt.Printf("Ties: %s.\n", ties)
+		}
+		if draft := g.Rules.DraftName(); draft != "standard" {
+			fmt.Printf("Draft: %s.\n", draft)
+		}
+		if rule := g.Rules.NoRepeatRule(); rule != NoRepeatPrevious {
+			fmt.Printf("No repeat: %s.\n", rule)
+		}
+		if order := g.Rules.ActionOrderRule(); order != ActionOrderWinner {
+			fmt.Printf("Manipulation order: %s.\n", order)
+		}
+		fmt.Println()
 	}
 
+	g.DealNewHand()
 	for {
-		// Play a hand (7 tricks), may end early in sudden death
+		// Play a hand (one trick per card), may end early in sudden death
 		gameEnded := g.PlayHand()
 		if gameEnded {
-			// Game ended in sudden death
+			// Game ended in sudden death or by forfeit. If everyone
+			// forfeited, the tricks decide after all.
+			g.settlePot()
 			winner := -1
-			maxTricks := 0
+			maxScore := -1
 			for _, player := range g.Players {
-				if player.TricksWon > maxTricks {
-					maxTricks = player.TricksWon
+				if player.Score > maxScore && (!g.forfeited(player.ID) || g.allForfeited()) {
+					maxScore = player.Score
 					winner = player.ID
 				}
 			}
-			if g.Stats != nil {
-				g.Stats.WinsByPlayer[winner]++
-			}
-			return winner
+			return g.finish(winner)
 		}
 
-		// Check if anyone has 10+ tricks
-		maxTricks := 0
+		// Check if anyone has reached the target
+		maxScore := 0
 		for _, player := range g.Players {
-			if player.TricksWon > maxTricks {
-				maxTricks = player.TricksWon
+			if player.Score > maxScore {
+				maxScore = player.Score
 			}
 		}
 
@@ -913,22 +1268,20 @@ func (g *Game) Run() int {
 		winner := g.CheckWinner()
 		if winner != -1 {
 			if g.Verbose {
-				fmt.Printf("\n🎉 GAME OVER! Player %d wins with %d tricks! 🎉\n", winner, g.Players[winner].TricksWon)
+				fmt.Printf("\n🎉 GAME OVER! Player %d wins with %s! 🎉\n", winner, g.scoreLabel(g.Players[winner].Score))
 				g.PrintScores()
 			}
-			if g.Stats != nil {
-				g.Stats.WinsByPlayer[winner]++
-			}
-			return winner
+			return g.finish(winner)
 		}
 
 		// Check if we should enter sudden death mode
-		if maxTricks >= 10 && !g.SuddenDeath {
+		if maxScore >= g.Rules.Target(g.NumPlayers) && !g.SuddenDeath {
 			g.SuddenDeath = true
 			if g.Verbose {
 				fmt.Println("\n⚡ SUDDEN DEATH! Multiple players tied at the top. Playing until someone breaks ahead! ⚡")
 				g.PrintScores()
 			}
+			g.emit(Event{Kind: EventSuddenDeath})
 		}
 
 		// Deal new hand
@@ -937,8 +1290,10 @@ func (g *Game) Run() int {
 	}
 }
 
-// RunStatistics runs multiple games and collects statistics
-func RunStatistics(numPlayers int, numGames int) {
+// RunStatistics runs multiple games and collects statistics. If newAgent is
+// not nil, every seat is played by an agent it creates; otherwise all seats
+// play greedily.
+func RunStatistics(numPlayers int, numGames int, newAgent AgentFactory) {
 	stats := NewStats(numPlayers)
 
 	fmt.Printf("Running %d games with %d players...\n", numGames, numPlayers)
@@ -946,6 +1301,11 @@ func RunStatistics(numPlayers int, numGames int) {
 	for i := 0; i < numGames; i++ {
 		game := NewGame(numPlayers, false)
 		game.Stats = stats
+		if newAgent != nil {
+			for p := 0; p < numPlayers; p++ {
+				game.Agents = append(game.Agents, newAgent())
+			}
+		}
 		game.Run()
 		stats.GamesPlayed++
 
@@ -956,6 +1316,30 @@ func RunStatistics(numPlayers int, numGames int) {
 
 	// Print statistics
 	fmt.Printf("\n=== STATISTICS FOR %d-PLAYER GAMES (%d games) ===\n", numPlayers, numGames)
+	if edition := configuredRules.Edition(); edition != printedEdition {
+		fmt.Printf("Edition: %d attributes, %d bees\n", edition.NumAttributes(), edition.DeckSize())
+	}
+	if decks := configuredRules.DeckCount(); decks > 1 {
+		fmt.Printf("Decks: %d\n", decks)
+	}
+	if ties := configuredRules.TiesName(); ties != TiesClosest {
+		fmt.Printf("Ties: %s\n", ties)
+	}
+	if draft := configuredRules.DraftName(); draft != "standard" {
+		fmt.Printf("Draft: %s\n", draft)
+	}
+	if rule := configuredRules.NoRepeatRule(); rule != NoRepeatPrevious {
+		fmt.Printf("No repeat: %s\n", rule)
+	}
+	if order := configuredRules.ActionOrderRule(); order != ActionOrderWinner {
+		fmt.Printf("Manipulation order: %s\n", order)
+	}
+	if scoring := configuredRules.ScoringName(); scoring != "tricks" {
+		fmt.Printf("Scoring: %s\n", scoring)
+	}
+	if configuredRules.JudgeName() == JudgePoints {
+		fmt.Printf("Judge: points, weights %v, ties by %s\n", configuredRules.Weights(configuredRules.Edition().NumAttributes()), configuredRules.TieBreakName())
+	}
 	fmt.Println()
 
 	// Win rates
@@ -990,29 +1374,411 @@ func RunStatistics(numPlayers int, numGames int) {
 		fmt.Printf("  Player %d: %d streaks\n", i, stats.ThreeStreaksByPlayer[i])
 	}
 	fmt.Println()
+
+	// How often the Manipulate phase lets the trick winner keep winning
+	if stats.TricksAfterActions > 0 {
+		twoStreaks, threeStreaks := 0, 0
+		for i := 0; i < numPlayers; i++ {
+			twoStreaks += stats.TwoStreaksByPlayer[i]
+			threeStreaks += stats.ThreeStreaksByPlayer[i]
+		}
+		fmt.Println("Leader Streaks:")
+		fmt.Printf("  Tricks won again by the previous winner: %.1f%% (an even share is %.1f%%)\n",
+			float64(stats.LeaderRepeats)/float64(stats.TricksAfterActions)*100, 100/float64(numPlayers))
+		fmt.Printf("  Streaks per game: %.2f of 2+ tricks, %.2f of 3+ tricks\n",
+			float64(twoStreaks)/float64(numGames), float64(threeStreaks)/float64(numGames))
+		if stats.SecretActions > 0 {
+			fmt.Printf("  Secret manipulations cancelled: %.1f%%\n", float64(stats.CancelledActions)/float64(stats.SecretActions)*100)
+		}
+		fmt.Println()
+	}
+
+	// Whether the schedule favors whoever drafts Slot 1
+	if stats.TricksJudged > 0 {
+		fmt.Println("Draft Advantage:")
+		fmt.Printf("  Tricks won by Slot 1's drafter: %.1f%% (an even share is %.1f%%)\n",
+			float64(stats.Slot1DrafterTricks)/float64(stats.TricksJudged)*100, 100/float64(numPlayers))
+		fmt.Println()
+	}
+
+	// What the slots sold for
+	if stats.SlotsAuctioned > 0 {
+		fmt.Printf("Auction (paid in %s):\n", configuredRules.Auction)
+		fmt.Printf("  Average winning bid: %.2f\n", float64(stats.BidsPaid)/float64(stats.SlotsAuctioned))
+		fmt.Printf("  Average price of Slot 1: %.2f\n", float64(stats.Slot1Paid)/float64(stats.SlotsAuctioned)*float64(configuredRules.Edition().NumAttributes()))
+		fmt.Printf("  Slots won without paying: %.1f%%\n", float64(stats.FreeSlots)/float64(stats.SlotsAuctioned)*100)
+		fmt.Println()
+	}
+
+	// What the scoring makes a game
+	fmt.Printf("Scoring (%s, first to %d):\n", configuredRules.ScoringName(), configuredRules.Target(numPlayers))
+	if stats.TricksJudged > 0 {
+		fmt.Printf("  Points per trick: %.2f\n", float64(stats.TrickPoints)/float64(stats.TricksJudged))
+	}
+	if stats.HandPoints > 0 {
+		fmt.Printf("  Points per hand for captured bees, all players: %.2f\n", float64(stats.HandPoints)/float64(stats.HandsPlayed))
+	}
+	fmt.Printf("  Average winning score: %.1f\n", float64(stats.WinningScores)/float64(numGames))
+	fmt.Printf("  Hands per game: %.2f\n", float64(stats.HandsPlayed)/float64(numGames))
+	fmt.Println()
+
+	// How decisive each trick is, and how evenly a hand's tricks spread
+	fmt.Println("Trick Variance:")
+	if stats.TricksJudged > 0 {
+		fmt.Printf("  Slots judged per trick: %.2f\n", float64(stats.SlotsJudged)/float64(stats.TricksJudged))
+		if configuredRules.JudgeName() == JudgePoints {
+			fmt.Printf("  Tricks with a tied top total: %.1f%%\n", float64(stats.PointTies)/float64(stats.TricksJudged)*100)
+			fmt.Printf("  Tricks left to the %s tie-break: %.1f%%\n", configuredRules.TieBreakName(), float64(stats.TieBreaks)/float64(stats.TricksJudged)*100)
+		} else {
+			fmt.Printf("  Tricks still tied after every slot: %.1f%%\n", float64(stats.TieBreaks)/float64(stats.TricksJudged)*100)
+		}
+		if stats.Replays > 0 {
+			fmt.Printf("  Replays per tied trick: %.2f\n", float64(stats.Replays)/float64(stats.TieBreaks))
+		}
+		if stats.SplitTricks > 0 {
+			fmt.Printf("  Tricks split between tied players: %.1f%%\n", float64(stats.SplitTricks)/float64(stats.TricksJudged)*100)
+		}
+	}
+	playerHands, sum, squares := 0, 0, 0
+	for tricks, count := range stats.HandTricks {
+		playerHands += count
+		sum += tricks * count
+		squares += tricks * tricks * count
+	}
+	if playerHands > 0 {
+		mean := float64(sum) / float64(playerHands)
+		variance := float64(squares)/float64(playerHands) - mean*mean
+		fmt.Printf("  Tricks per player per hand: mean %.2f, variance %.2f\n", mean, variance)
+		for tricks, count := range stats.HandTricks {
+			fmt.Printf("    %d tricks: %.1f%%\n", tricks, float64(count)/float64(playerHands)*100)
+		}
+	}
+	fmt.Println()
+
+	// Forfeits only happen with external bots
+	totalForfeits := 0
+	for i := 0; i < numPlayers; i++ {
+		totalForfeits += stats.ForfeitsByPlayer[i]
+	}
+	if totalForfeits > 0 {
+		fmt.Println("Forfeits:")
+		for i := 0; i < numPlayers; i++ {
+			fmt.Printf("  Player %d: %d forfeits\n", i, stats.ForfeitsByPlayer[i])
+		}
+		fmt.Println()
+	}
+}
+
+// printUsage prints the command line help
+func printUsage() {
+	fmt.Println("Usage: go run . [num_players]")
+	fmt.Println("       go run . stats [num_games] [agent]")
+	fmt.Println("       go run . kingmaking [num_games]")
+	fmt.Println("       go run . train [num_games] [weights_file]")
+	fmt.Println("       go run . evaluate <agent> [num_games]")
+	fmt.Println("       go run . tournament <num_players> <num_games> <agent> [agent...]")
+	fmt.Println("       go run . evolve [generations] [params_file]")
+	fmt.Println("       go run . play [num_players] [human_seats] [agent]")
+	fmt.Println("       go run . tui [num_players] [human_seats] [agent]")
+	fmt.Println("       go run . hotseat [num_players] [human_seats] [agent]")
+	fmt.Println("       go run . serve [address]")
+	fmt.Println("       go run . lan [address]")
+	fmt.Println("       go run . local [address] [web_dir]")
+	fmt.Println("       go run . conformance generate|check [dir]")
+	fmt.Println("       go run . fuzz [num_games] [first_seed]")
+	fmt.Println("       go run . --check <any of the above>")
+	fmt.Println("       go run . --cards <catalog.json> <any of the above>")
+	fmt.Println("       go run . --rules <rules.json> <any of the above>")
+	fmt.Println()
+	fmt.Println("  num_players: 2-5 (default: 4)")
+	fmt.Println("  stats: Run statistical analysis across all player counts, every seat played by agent")
+	fmt.Println("  kingmaking: Compare leader-targeting manipulation against greedy play (3-5 players)")
+	fmt.Println("  train: Learn a manipulation policy by self-play (default: 20000 games, weights.json)")
+	fmt.Println("  evaluate: Play an agent against greedy opponents across all player counts")
+	fmt.Println("  tournament: Play agents against each other, rotating seats every game")
+	fmt.Println("  evolve: Tune heuristic parameters with a genetic algorithm (default: 20 generations, params.json)")
+	fmt.Println("  play: Play at the terminal; human_seats is a comma-separated list (default: 0), agent plays the rest")
+	fmt.Println("  tui: Like play, in a full-screen view with the Queen's Favor ring and an animated Judge")
+	fmt.Println("  hotseat: Pass-and-play on one screen; hands stay hidden between turns (default: every seat human)")
+	fmt.Println("  serve: Run the HTTP JSON API for creating and playing games (default: localhost:8080, see api.go)")
+	fmt.Println("  lan: Host multiplayer lobbies over WebSockets for the local network (default: :8080, see lobby.go)")
+	fmt.Println("  local: Serve the web app with Go AI, the API and lobbies for offline play (default: localhost:8080,")
+	fmt.Println("         the embedded web app or ../web; see assets_embed.go to build a single binary)")
+	fmt.Println("  conformance: Write seeded golden game traces, or replay them through the Go engine and the web")
+	fmt.Println("               app's BeeGame (with Node) and report the first divergence (default: conformance/golden)")
+	fmt.Println("  fuzz: Play random legal moves under varied rules, checking invariants (default: 10000 games)")
+	fmt.Println("  --check: Validate card, board and score invariants after every phase of every game")
+	fmt.Println("  --cards: Name the bees from a card catalog instead of the printed deck (see cards.json)")
+	fmt.Println("  --rules: Play new games by rules from a file, such as another edition's attributes, draft")
+	fmt.Println("           schedule, manipulation rules, scoring, or extra decks and tie rules (see rules/)")
+	fmt.Println("  agent: greedy, leader, learned[:weights_file], heuristic[:params_file],")
+	fmt.Println("         easy, medium, hard, noisy:<temperature>:<epsilon>:<agent>,")
+	fmt.Println("         or bot:[<time_limit_ms>:]<command> for an external program (see bot.go)")
+	fmt.Println("  num_games: Number of games per player count (default: 1000)")
+}
+
+// parseNumGames reads the optional game count at os.Args[argIdx]
+func parseNumGames(argIdx int, defaultGames int) int {
+	numGames := defaultGames
+	if len(os.Args) > argIdx {
+		var err error
+		numGames, err = strconv.Atoi(os.Args[argIdx])
+		if err != nil || numGames < 1 {
+			printUsage()
+			os.Exit(1)
+		}
+	}
+	return numGames
+}
+
+// parsePlayArgs reads [num_players] [human_seats] [agent] for the
+// interactive modes. Humans default to seat 0, or to every seat if allHuman.
+func parsePlayArgs(allHuman bool) (int, []int, AgentFactory) {
+	numPlayers := 4
+	humanSeats := []int{0}
+	newAgent := AgentFactory(func() Agent { return GreedyAgent{} })
+	var err error
+	if len(os.Args) > 2 {
+		numPlayers, err = strconv.Atoi(os.Args[2])
+		if err != nil || numPlayers < 2 || numPlayers > 5 {
+			printUsage()
+			os.Exit(1)
+		}
+	}
+	if allHuman {
+		humanSeats = make([]int, numPlayers)
+		for i := range humanSeats {
+			humanSeats[i] = i
+		}
+	}
+	if len(os.Args) > 3 {
+		if humanSeats, err = parseSeats(os.Args[3], numPlayers); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	}
+	if len(os.Args) > 4 {
+		if newAgent, err = ParseAgent(os.Args[4]); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	}
+	return numPlayers, humanSeats, newAgent
 }
 
 func main() {
 	// Seed random number generator
 	rand.Seed(time.Now().UnixNano())
 
+	// The WebAssembly build has no command line; it serves the browser
+	if runtime.GOOS == "js" {
+		RunWASMBridge()
+		return
+	}
+
+	// --check validates invariants in every game, whatever the mode
+	for i := 1; i < len(os.Args); i++ {
+		if os.Args[i] == "--check" {
+			checkInvariants = true
+			os.Args = append(os.Args[:i], os.Args[i+1:]...)
+			i--
+		}
+	}
+
+	// --cards replaces the catalog that names the bees
+	for i := 1; i < len(os.Args); i++ {
+		if os.Args[i] == "--cards" {
+			if i+1 >= len(os.Args) {
+				fmt.Println("Error: --cards needs a catalog file")
+				os.Exit(1)
+			}
+			catalog, err := LoadCatalog(os.Args[i+1])
+			if err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			cardCatalog = catalog
+			os.Args = append(os.Args[:i], os.Args[i+2:]...)
+			i--
+		}
+	}
+
+	// --rules replaces the rules new games are played by
+	for i := 1; i < len(os.Args); i++ {
+		if os.Args[i] == "--rules" {
+			if i+1 >= len(os.Args) {
+				fmt.Println("Error: --rules needs a rules file")
+				os.Exit(1)
+			}
+			rules, err := LoadRules(os.Args[i+1])
+			for numPlayers := 2; numPlayers <= 5 && err == nil; numPlayers++ {
+				// Every mode may play any table size
+				err = rules.Validate(numPlayers)
+			}
+			if err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			configuredRules = rules
+			os.Args = append(os.Args[:i], os.Args[i+2:]...)
+			i--
+		}
+	}
+
 	// Parse command line arguments
 	if len(os.Args) > 1 && os.Args[1] == "stats" {
 		// Statistics mode
-		numGames := 1000
-		if len(os.Args) > 2 {
+		numGames := parseNumGames(2, 1000)
+		var newAgent AgentFactory
+		if len(os.Args) > 3 {
 			var err error
-			numGames, err = strconv.Atoi(os.Args[2])
-			if err != nil {
-				fmt.Println("Usage: go run sim.go stats [num_games]")
+			if newAgent, err = ParseAgent(os.Args[3]); err != nil {
+				fmt.Println("Error:", err)
 				os.Exit(1)
 			}
 		}
 
 		// Run statistics for all player counts
 		for numPlayers := 2; numPlayers <= 5; numPlayers++ {
-			RunStatistics(numPlayers, numGames)
+			RunStatistics(numPlayers, numGames, newAgent)
+		}
+	} else if len(os.Args) > 1 && os.Args[1] == "kingmaking" {
+		// Kingmaking mode: only meaningful with bystanders at the table
+		numGames := parseNumGames(2, 1000)
+		for numPlayers := 3; numPlayers <= 5; numPlayers++ {
+			RunKingmakingReport(numPlayers, numGames, configuredRules)
+		}
+	} else if len(os.Args) > 1 && os.Args[1] == "train" {
+		// Training mode: learn manipulation weights, then check them against greedy
+		numGames := parseNumGames(2, 20000)
+		weightsFile := defaultWeightsFile
+		if len(os.Args) > 3 {
+			weightsFile = os.Args[3]
+		}
+
+		model := TrainManipulationPolicy(numGames, rand.Int63())
+		if err := SaveValueModel(weightsFile, model); err != nil {
+			fmt.Println("Error saving weights:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("\nSaved weights to %s\n", weightsFile)
+
+		spec := "learned:" + weightsFile
+		RunEvaluation(spec, func() Agent { return &LearnedAgent{Model: model} }, 1000)
+	} else if len(os.Args) > 2 && os.Args[1] == "evaluate" {
+		// Evaluation mode: any agent against greedy opponents
+		newAgent, err := ParseAgent(os.Args[2])
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		RunEvaluation(os.Args[2], newAgent, parseNumGames(3, 1000))
+	} else if len(os.Args) > 4 && os.Args[1] == "tournament" {
+		// Tournament mode: agents against each other with rotating seats
+		numPlayers, err := strconv.Atoi(os.Args[2])
+		if err != nil || numPlayers < 2 || numPlayers > 5 {
+			printUsage()
+			os.Exit(1)
+		}
+		numGames := parseNumGames(3, 1000)
+		specs := os.Args[4:]
+		factories := make([]AgentFactory, len(specs))
+		for i, spec := range specs {
+			if factories[i], err = ParseAgent(spec); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+		}
+		RunTournament(specs, factories, numPlayers, numGames)
+	} else if len(os.Args) > 1 && os.Args[1] == "evolve" {
+		// Evolution mode: tune heuristic parameters, then measure the winner
+		generations := parseNumGames(2, defaultGenerations)
+		paramsFile := defaultParamsFile
+		if len(os.Args) > 3 {
+			paramsFile = os.Args[3]
+		}
+
+		params := EvolveHeuristicParams(generations, rand.Int63())
+		if err := SaveHeuristicParams(paramsFile, params); err != nil {
+			fmt.Println("Error saving parameters:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("\nBest parameters: %s\n", params)
+		fmt.Printf("Saved parameters to %s\n", paramsFile)
+
+		spec := "heuristic:" + paramsFile
+		RunEvaluation(spec, func() Agent { return &HeuristicAgent{Params: params} }, evaluationGames)
+	} else if len(os.Args) > 1 && os.Args[1] == "play" {
+		// Interactive mode: humans against AI opponents
+		RunInteractiveGame(parsePlayArgs(false))
+	} else if len(os.Args) > 1 && os.Args[1] == "tui" {
+		// Full-screen interactive mode
+		numPlayers, humanSeats, newAgent := parsePlayArgs(false)
+		RunTUIGame(numPlayers, humanSeats, newAgent, false)
+	} else if len(os.Args) > 1 && os.Args[1] == "serve" {
+		// HTTP API mode
+		addr := "localhost:8080"
+		if len(os.Args) > 2 {
+			addr = os.Args[2]
+		}
+		RunAPIServer(addr)
+	} else if len(os.Args) > 1 && os.Args[1] == "lan" {
+		// Multiplayer lobby mode over WebSockets
+		addr := ":8080"
+		if len(os.Args) > 2 {
+			addr = os.Args[2]
+		}
+		RunLobbyServer(addr)
+	} else if len(os.Args) > 1 && os.Args[1] == "local" {
+		// Offline mode: the web app and everything it can talk to
+		addr := "localhost:8080"
+		if len(os.Args) > 2 {
+			addr = os.Args[2]
+		}
+		dir := ""
+		if len(os.Args) > 3 {
+			dir = os.Args[3]
+		}
+		RunLocalServer(addr, dir)
+	} else if len(os.Args) > 1 && os.Args[1] == "fuzz" {
+		// Random legal play under varied rules, checking invariants
+		numGames := parseNumGames(2, 10000)
+		firstSeed := time.Now().UnixNano()
+		if len(os.Args) > 3 {
+			seed, err := strconv.ParseInt(os.Args[3], 10, 64)
+			if err != nil {
+				fmt.Println("Error: seed must be a number")
+				os.Exit(1)
+			}
+			firstSeed = seed
+		}
+		if !RunFuzzer(numGames, firstSeed) {
+			os.Exit(1)
+		}
+	} else if len(os.Args) > 2 && os.Args[1] == "conformance" {
+		// Cross-implementation checks against golden traces
+		dir := "conformance/golden"
+		if len(os.Args) > 3 {
+			dir = os.Args[3]
+		}
+		switch os.Args[2] {
+		case "generate":
+			if err := GenerateGoldens(dir, 500); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+		case "check":
+			if !CheckGoldens(dir, "../web/eye-of-the-beeholder.html") {
+				os.Exit(1)
+			}
+		default:
+			printUsage()
+			os.Exit(1)
 		}
+	} else if len(os.Args) > 1 && os.Args[1] == "hotseat" {
+		// Pass-and-play mode: several people sharing one screen
+		numPlayers, humanSeats, newAgent := parsePlayArgs(true)
+		RunTUIGame(numPlayers, humanSeats, newAgent, true)
 	} else {
 		// Single game mode
 		numPlayers := 4 // Default to 4 players
@@ -1020,12 +1786,7 @@ func main() {
 			var err error
 			numPlayers, err = strconv.Atoi(os.Args[1])
 			if err != nil || numPlayers < 2 || numPlayers > 5 {
-				fmt.Println("Usage: go run sim.go [num_players]")
-				fmt.Println("       go run sim.go stats [num_games]")
-				fmt.Println()
-				fmt.Println("  num_players: 2-5 (default: 4)")
-				fmt.Println("  stats: Run statistical analysis across all player counts")
-				fmt.Println("  num_games: Number of games per player count (default: 1000)")
+				printUsage()
 				os.Exit(1)
 			}
 		}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// playSeededTable plays one silent game with the given agents in seat order
+// and returns the winning seat
+func playSeededTable(seed int64, agents []Agent) int {
+	game := NewSeededGame(len(agents), seed, false)
+	game.Agents = agents
+	return game.Run()
+}
+
+// RunTournament seats the agents around the table, rotating seats every game
+// so that no agent keeps a positional advantage, and reports each agent's wins
+func RunTournament(specs []string, factories []AgentFactory, numPlayers int, numGames int) {
+	wins := make([]int, len(specs))
+	games := make([]int, len(specs))
+	forfeits := make([]int, len(specs))
+
+	fmt.Printf("Running %d tournament games with %d players...\n", numGames, numPlayers)
+
+	for i := 0; i < numGames; i++ {
+		seated := make([]int, numPlayers)
+		agents := make([]Agent, numPlayers)
+		for seat := 0; seat < numPlayers; seat++ {
+			seated[seat] = (seat + i) % len(specs)
+			agents[seat] = factories[seated[seat]]()
+			games[seated[seat]]++
+		}
+
+		game := NewSeededGame(numPlayers, rand.Int63(), false)
+		game.Agents = agents
+		winner := game.Run()
+		wins[seated[winner]]++
+		for seat, spec := range seated {
+			if game.forfeited(seat) {
+				forfeits[spec]++
+			}
+		}
+
+		if (i+1)%100 == 0 {
+			fmt.Printf("  Completed %d/%d games\n", i+1, numGames)
+		}
+	}
+
+	fmt.Printf("\n=== TOURNAMENT RESULTS FOR %d-PLAYER GAMES (%d games) ===\n", numPlayers, numGames)
+	fmt.Println()
+	for i, spec := range specs {
+		winRate := 0.0
+		if games[i] > 0 {
+			winRate = float64(wins[i]) / float64(games[i]) * 100
+		}
+		fmt.Printf("  %-24s %5d wins in %5d games (%.1f%%)", spec, wins[i], games[i], winRate)
+		if forfeits[i] > 0 {
+			fmt.Printf(", %d forfeits", forfeits[i])
+		}
+		fmt.Println()
+	}
+	fmt.Printf("  Fair share: %.1f%%\n", 100/float64(numPlayers))
+	fmt.Println()
+}
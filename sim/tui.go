@@ -0,0 +1,514 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ANSI escape sequences used by the full-screen interface
+const (
+	ansiClear   = "\x1b[2J\x1b[H"
+	ansiScroll  = "\x1b[3J" // Also forget the scrollback, where hands could be read
+	ansiReset   = "\x1b[0m"
+	ansiBold    = "\x1b[1m"
+	ansiDim     = "\x1b[2m"
+	ansiReverse = "\x1b[7m"
+	ansiRed     = "\x1b[31m"
+	ansiGreen   = "\x1b[32m"
+	ansiYellow  = "\x1b[33m"
+	ansiCyan    = "\x1b[36m"
+)
+
+const (
+	tuiLogLines   = 6  // Recent events kept under the table
+	tuiSlotWidth  = 23 // Width of a slot box on the ring
+	tuiLabelWidth = 11 // Width of the attribute names beside the hand grid
+	tuiCellWidth  = 10 // Width of a card column in the hand grid
+)
+
+// TUI redraws the whole table after every event and animates the Judge
+// phase one slot at a time. It also seats humans, who answer at the bottom
+// of the screen.
+//
+// With Hotseat set, several people share the screen: no hand is shown
+// between turns, and the screen is cleared and waits for "ready" whenever a
+// different person has to decide.
+type TUI struct {
+	in      *bufio.Scanner
+	out     io.Writer
+	viewer  int           // Seat whose hand is shown, or -1
+	delay   time.Duration // Pause between animation frames
+	Hotseat bool
+	holder  int // Seat of the person last handed the screen, or -1
+
+	plays   []Play         // Cards in the current trick
+	status  map[int]string // Judge result per player in the current trick
+	judging int            // Slot being judged, or -1
+	log     []string
+}
+
+// NewTUI creates an interface showing the viewer's hand
+func NewTUI(in *bufio.Scanner, out io.Writer, viewer int, delay time.Duration) *TUI {
+	return &TUI{in: in, out: out, viewer: viewer, delay: delay, judging: -1, holder: -1}
+}
+
+// Seat returns an agent that lets a human play the given seat through the TUI
+func (t *TUI) Seat() Agent {
+	return &tuiSeat{t}
+}
+
+// Observe updates the screen for each event, pausing so people can follow
+func (t *TUI) Observe(g *Game, e Event) {
+	switch e.Kind {
+	case EventHandStart:
+		t.plays = nil
+		t.addLog(fmt.Sprintf("Hand %d begins. Player %d leads.", g.HandNumber, e.PlayerID))
+		t.show(g, 1)
+	case EventBid:
+		t.addLog(fmt.Sprintf("Player %d wins Slot %d for %s (bids %s).", e.PlayerID, e.SlotIndex+1,
+			g.auctionUnit(e.Bids[e.PlayerID]), joinInts(e.Bids)))
+		t.show(g, 1)
+	case EventDraft:
+		t.addLog(fmt.Sprintf("Player %d places %s in Slot %d.", e.PlayerID, e.Token, e.SlotIndex+1))
+		t.show(g, 1)
+	case EventReveal:
+		t.plays = e.Plays
+		t.status = make(map[int]string)
+		t.addLog("Bees are presented.")
+		t.show(g, 2)
+	case EventReplay:
+		// The new bees replace the tied ones on the table
+		plays, tied := make([]Play, len(t.plays)), []int{}
+		for i, play := range t.plays {
+			plays[i] = play
+			for _, replay := range e.Plays {
+				if replay.PlayerID == play.PlayerID {
+					plays[i] = replay
+					tied = append(tied, play.PlayerID)
+				}
+			}
+		}
+		t.plays = plays
+		t.addLog(fmt.Sprintf("Player %s tie and present again.", joinInts(tied)))
+		t.show(g, 2)
+	case EventJudgeSlot:
+		t.judging = e.SlotIndex
+		for _, id := range e.Eliminated {
+			t.status[id] = fmt.Sprintf("out at Slot %d", e.SlotIndex+1)
+		}
+		if len(e.Eliminated) == 0 {
+			t.addLog(fmt.Sprintf("Slot %d (%s): nobody eliminated.", e.SlotIndex+1, e.Token))
+		} else {
+			t.addLog(fmt.Sprintf("Slot %d (%s): Player %s out.", e.SlotIndex+1, e.Token, joinInts(e.Eliminated)))
+		}
+		t.show(g, 2)
+	case EventJudgeTotals:
+		totals := make([]string, 0, len(e.Totals))
+		for id, points := range e.Totals {
+			totals = append(totals, fmt.Sprintf("P%d %d", id, points))
+		}
+		message := fmt.Sprintf("Points: %s.", strings.Join(totals, ", "))
+		if len(e.Eliminated) > 0 {
+			message += fmt.Sprintf(" Player %s out.", joinInts(e.Eliminated))
+		}
+		t.addLog(message)
+		t.show(g, 2)
+	case EventTrickWon:
+		t.judging = -1
+		if e.Shares != nil {
+			t.status[e.PlayerID] = "takes the split trick"
+			t.addLog(fmt.Sprintf("Trick %d is split; Player %d takes the bees and the lead.", g.TrickNumber, e.PlayerID))
+			t.show(g, 4)
+			break
+		}
+		t.status[e.PlayerID] = "wins the trick"
+		message := fmt.Sprintf("Player %d wins trick %d", e.PlayerID, g.TrickNumber)
+		for _, play := range t.plays {
+			if play.PlayerID == e.PlayerID && play.Card.Name() != "" {
+				message += " with the " + play.Card.Name()
+			}
+		}
+		t.addLog(message + ".")
+		t.show(g, 4)
+	case EventAction:
+		if e.Cancelled {
+			t.addLog(fmt.Sprintf("Player %d's choice to %s is cancelled.", e.PlayerID, e.Action))
+		} else {
+			t.addLog(fmt.Sprintf("Player %d chooses to %s.", e.PlayerID, e.Action))
+		}
+		t.show(g, 1)
+	case EventHandEnd:
+		t.addLog(fmt.Sprintf("Hand %d is over.", g.HandNumber))
+		t.show(g, 2)
+	case EventSuddenDeath:
+		t.addLog("SUDDEN DEATH! Play continues until someone breaks ahead.")
+		t.show(g, 2)
+	case EventGameOver:
+		t.addLog(fmt.Sprintf("GAME OVER! Player %d wins with %s.", e.PlayerID, g.scoreLabel(g.Players[e.PlayerID].Score)))
+		t.show(g, 0)
+	}
+}
+
+// addLog keeps the most recent messages
+func (t *TUI) addLog(message string) {
+	t.log = append(t.log, message)
+	if len(t.log) > tuiLogLines {
+		t.log = t.log[len(t.log)-tuiLogLines:]
+	}
+}
+
+// show redraws the screen and waits for the given number of frames
+func (t *TUI) show(g *Game, frames int) {
+	t.render(g)
+	time.Sleep(time.Duration(frames) * t.delay)
+}
+
+// render draws the scores, the ring, the current trick, the hand and the log
+func (t *TUI) render(g *Game) {
+	var b strings.Builder
+	b.WriteString(ansiClear)
+
+	// TrickNumber moves past the last trick once it has been played
+	title := fmt.Sprintf("EYE OF THE BEE-HOLDER   Hand %d   Trick %d", g.HandNumber, min(g.TrickNumber, g.Rules.HandSize))
+	if g.SuddenDeath {
+		title += "   " + ansiRed + "SUDDEN DEATH" + ansiReset + ansiBold
+	}
+	fmt.Fprintf(&b, "%s%s%s\n\n", ansiBold, title, ansiReset)
+
+	scores := make([]string, len(g.Players))
+	for i, p := range g.Players {
+		scores[i] = fmt.Sprintf("P%d: %d", p.ID, p.Score)
+		if p.ID == g.CurrentLeader {
+			scores[i] += "*"
+		}
+		if p.ID == t.viewer {
+			scores[i] = ansiBold + scores[i] + ansiReset
+		}
+	}
+	fmt.Fprintf(&b, "Tricks  %s   (* leads)\n\n", strings.Join(scores, "   "))
+
+	t.renderRing(&b, g)
+	t.renderTrick(&b)
+	t.renderHand(&b, g)
+
+	b.WriteString("\n")
+	for _, line := range t.log {
+		fmt.Fprintf(&b, "%s%s%s\n", ansiDim, line, ansiReset)
+	}
+	fmt.Fprint(t.out, b.String())
+}
+
+// renderRing draws the slots around the Queen's Favor, Slot 1 at the top
+// and the rest clockwise: down the right side, across the bottom when the
+// sides do not share them evenly, and back up the left
+func (t *TUI) renderRing(b *strings.Builder, g *Game) {
+	pad := strings.Repeat(" ", tuiSlotWidth)
+	name := "QUEEN'S FAVOR"
+	center := strings.Repeat(" ", (tuiSlotWidth-len(name))/2) + name
+
+	numSlots := len(g.Board.Slots)
+	side := (numSlots - 1) / 2
+	fmt.Fprintf(b, "%s%s%s\n", pad, t.slotBox(g, 0), pad)
+	for row := 0; row < side; row++ {
+		fmt.Fprintf(b, "%s%s%s\n", t.slotBox(g, numSlots-1-row), pad, t.slotBox(g, 1+row))
+		if row == (side-1)/2 {
+			fmt.Fprintf(b, "%s%s%s%s\n", pad, ansiYellow+ansiBold, center, ansiReset)
+		}
+	}
+	if side == 0 {
+		fmt.Fprintf(b, "%s%s%s%s\n", pad, ansiYellow+ansiBold, center, ansiReset)
+	}
+	if numSlots%2 == 0 {
+		fmt.Fprintf(b, "%s%s%s\n", pad, t.slotBox(g, side+1), pad)
+	}
+	b.WriteString("\n")
+}
+
+// slotBox formats one slot, highlighted while the Judge checks it
+func (t *TUI) slotBox(g *Game, slotIdx int) string {
+	text := fmt.Sprintf("%d empty", slotIdx+1)
+	if token := g.Board.Slots[slotIdx]; token != nil {
+		text = fmt.Sprintf("%d %s", slotIdx+1, token)
+	}
+	box := fmt.Sprintf("[%-*s]", tuiSlotWidth-2, text)
+	if slotIdx == t.judging {
+		return ansiReverse + box + ansiReset
+	}
+	if g.Board.Locked[slotIdx] {
+		return ansiRed + box + ansiReset
+	}
+	return ansiCyan + box + ansiReset
+}
+
+// renderTrick lists the cards presented this trick and how each fared
+func (t *TUI) renderTrick(b *strings.Builder) {
+	if len(t.plays) == 0 {
+		return
+	}
+	for _, play := range t.plays {
+		status := t.status[play.PlayerID]
+		colour := ""
+		switch {
+		case strings.HasPrefix(status, "out"):
+			colour = ansiDim
+		case status != "":
+			colour = ansiGreen + ansiBold
+		}
+		fmt.Fprintf(b, "%sP%d %s %s%s\n", colour, play.PlayerID, play.Card, status, ansiReset)
+	}
+	b.WriteString("\n")
+}
+
+// renderHand draws the viewer's hand as a grid, one column per card and one
+// row per attribute in slot order, with the cells that match their slot
+// highlighted
+func (t *TUI) renderHand(b *strings.Builder, g *Game) {
+	if t.viewer < 0 || t.viewer >= len(g.Players) {
+		return
+	}
+	hand := g.Players[t.viewer].Hand
+	fmt.Fprintf(b, "Player %d's hand\n", t.viewer)
+	if len(hand) == 0 {
+		b.WriteString("  (empty)\n")
+		return
+	}
+
+	fmt.Fprintf(b, "%-*s", tuiLabelWidth, "")
+	for i := range hand {
+		fmt.Fprintf(b, "%-*s", tuiCellWidth, fmt.Sprintf("  %d %s", i+1, cardNumber(hand[i])))
+	}
+	b.WriteString("\n")
+
+	edition := g.Edition()
+	for _, row := range attributeRows(g) {
+		label := "  " + edition.AttributeName(row.attr)
+		if row.slot >= 0 {
+			label = fmt.Sprintf("%d %s", row.slot+1, edition.AttributeName(row.attr))
+		}
+		fmt.Fprintf(b, "%-*s", tuiLabelWidth, label)
+
+		for _, card := range hand {
+			cell := fmt.Sprintf("%-*s", tuiCellWidth, edition.ValueName(row.attr, card.Attributes[row.attr]))
+			token := (*AttributeToken)(nil)
+			if row.slot >= 0 {
+				token = g.Board.Slots[row.slot]
+			}
+			if token != nil && card.Matches(token.Attribute, token.Value) {
+				cell = ansiGreen + ansiBold + cell + ansiReset
+			} else {
+				cell = ansiDim + cell + ansiReset
+			}
+			b.WriteString(cell)
+		}
+		b.WriteString("\n")
+	}
+
+	for _, peek := range g.Players[t.viewer].knownHeld() {
+		fmt.Fprintf(b, "  Seen in Player %d's hand: %s\n", peek.Holder, peek.Card)
+	}
+}
+
+// attributeRow is one row of the hand grid
+type attributeRow struct {
+	attr Attribute
+	slot int // Slot holding the attribute, or -1
+}
+
+// attributeRows orders the attributes by the slot that holds them, with any
+// attribute not yet on the board at the end
+func attributeRows(g *Game) []attributeRow {
+	rows := make([]attributeRow, g.Edition().NumAttributes())
+	for attr := range rows {
+		rows[attr] = attributeRow{attr: Attribute(attr), slot: -1}
+	}
+	for slot, token := range g.Board.Slots {
+		if token != nil {
+			rows[token.Attribute].slot = slot
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if (rows[i].slot < 0) != (rows[j].slot < 0) {
+			return rows[j].slot < 0
+		}
+		return rows[i].slot < rows[j].slot
+	})
+	return rows
+}
+
+// prompt redraws the table for the viewer and reads one line at the bottom
+func (t *TUI) prompt(g *Game, viewer int, message string, question string) string {
+	if t.Hotseat && t.holder != viewer {
+		t.handOver(viewer)
+	}
+	t.viewer = viewer
+	t.render(g)
+	if message != "" {
+		fmt.Fprintf(t.out, "\n%s%s%s", ansiRed, message, ansiReset)
+	}
+	fmt.Fprintf(t.out, "\n%s> ", question)
+	return t.readLine()
+}
+
+// readLine reads one line, leaving the game if input runs out
+func (t *TUI) readLine() string {
+	if !t.in.Scan() {
+		fmt.Fprintln(t.out, "\nGoodbye!")
+		os.Exit(0)
+	}
+	return strings.TrimSpace(t.in.Text())
+}
+
+// handOver blanks the screen until the next person confirms they have it
+func (t *TUI) handOver(seat int) {
+	for {
+		fmt.Fprintf(t.out, "%s%sPass the screen to Player %d.\n\nPlayer %d, type \"ready\" when nobody else can see> ", ansiClear, ansiScroll, seat, seat)
+		if strings.EqualFold(t.readLine(), "ready") {
+			t.holder = seat
+			return
+		}
+	}
+}
+
+// conceal hides the hand once a hot-seat player has decided, so it stays
+// hidden while the game plays on
+func (t *TUI) conceal() {
+	if t.Hotseat {
+		t.viewer = -1
+	}
+}
+
+// tuiSeat is a human playing through the full-screen interface
+type tuiSeat struct {
+	t *TUI
+}
+
+func (s *tuiSeat) ChooseToken(g *Game, player *Player, availableTokens []Attribute, slotIdx int) (Attribute, int) {
+	defer s.t.conceal()
+	edition := g.Edition()
+	tiles := make([]string, len(availableTokens))
+	for i, attr := range availableTokens {
+		tiles[i] = fmt.Sprintf("%d) %s %s", i+1, edition.AttributeName(attr), edition.ValueList(attr, "/"))
+	}
+	question := fmt.Sprintf("Tiles: %s\nPlayer %d, choose a tile and side for Slot %d (e.g. \"1 %s\")",
+		strings.Join(tiles, "  "), player.ID, slotIdx+1, edition.ValueName(availableTokens[0], 1))
+
+	message := ""
+	for {
+		fields := strings.Fields(s.t.prompt(g, player.ID, message, question))
+		if len(fields) != 2 {
+			message = "Enter a tile number or name followed by the side to show."
+			continue
+		}
+		attr, ok := parseAttribute(fields[0], edition, availableTokens)
+		if !ok {
+			message = fmt.Sprintf("%q is not one of the available tiles.", fields[0])
+			continue
+		}
+		value, ok := edition.ParseValue(fields[1], attr)
+		if !ok {
+			message = fmt.Sprintf("%s is one of %s.", edition.AttributeName(attr), edition.ValueList(attr, ", "))
+			continue
+		}
+		return attr, value
+	}
+}
+
+// ChooseCard takes the bee privately; the game reveals every choice together
+// once all players have chosen
+func (s *tuiSeat) ChooseCard(g *Game, player *Player) int {
+	defer s.t.conceal()
+	question := fmt.Sprintf("Player %d, present which bee? (1-%d)", player.ID, len(player.Hand))
+	message := ""
+	for {
+		var n int
+		if _, err := fmt.Sscan(s.t.prompt(g, player.ID, message, question), &n); err != nil || n < 1 || n > len(player.Hand) {
+			message = fmt.Sprintf("Enter a number from 1 to %d.", len(player.Hand))
+			continue
+		}
+		return n - 1
+	}
+}
+
+func (s *tuiSeat) ChooseAction(g *Game, player *Player, previousAction *Action) Action {
+	defer s.t.conceal()
+	question := fmt.Sprintf("Player %d, manipulate the Queen's Favor (%s)", player.ID, actionUsage(g.Rules.ActionNames()))
+	if reminder := repeatReminder(g, previousAction); reminder != "" {
+		question = reminder + "\n" + question
+	}
+
+	legal := g.legalActions(player, previousAction)
+	message := ""
+	for {
+		action, err := parseAction(s.t.prompt(g, player.ID, message, question), g)
+		if err != nil {
+			message = err.Error()
+			continue
+		}
+		for _, l := range legal {
+			if actionsMatch(l, action) {
+				return l
+			}
+		}
+		message = illegalActionMessage(g, action, previousAction)
+	}
+}
+
+// ChooseBid takes a sealed bid privately; the game reveals every bid once
+// all players have bid
+func (s *tuiSeat) ChooseBid(g *Game, player *Player, availableTokens []Attribute, slotIdx int, maxBid int) int {
+	if maxBid == 0 {
+		return 0
+	}
+	defer s.t.conceal()
+	question := fmt.Sprintf("Player %d, bid for Slot %d (0 to %s)", player.ID, slotIdx+1, g.auctionUnit(maxBid))
+	message := ""
+	for {
+		var n int
+		if _, err := fmt.Sscan(s.t.prompt(g, player.ID, message, question), &n); err != nil || n < 0 || n > maxBid {
+			message = fmt.Sprintf("Enter a number from 0 to %d.", maxBid)
+			continue
+		}
+		return n
+	}
+}
+
+func (s *tuiSeat) ChooseDiscards(g *Game, player *Player, n int) []int {
+	defer s.t.conceal()
+	question := fmt.Sprintf("Player %d, discard which %d bees to pay? (e.g. \"1 3\")", player.ID, n)
+	message := ""
+	for {
+		discards, err := parseDiscards(s.t.prompt(g, player.ID, message, question), n, len(player.Hand))
+		if err != nil {
+			message = err.Error()
+			continue
+		}
+		return discards
+	}
+}
+
+// RunTUIGame plays one game in the full-screen interface with people in the
+// given seats and newAgent in the rest. In hot-seat mode the people share
+// the screen and only ever see their own hand.
+func RunTUIGame(numPlayers int, humanSeats []int, newAgent AgentFactory, hotseat bool) {
+	viewer := humanSeats[0]
+	if hotseat {
+		viewer = -1
+	}
+	tui := NewTUI(bufio.NewScanner(os.Stdin), os.Stdout, viewer, 600*time.Millisecond)
+	tui.Hotseat = hotseat
+	game := NewGame(numPlayers, false)
+	game.Observers = append(game.Observers, tui)
+	game.Agents = make([]Agent, numPlayers)
+	for i := range game.Agents {
+		game.Agents[i] = newAgent()
+	}
+	for _, seat := range humanSeats {
+		game.Agents[seat] = tui.Seat()
+	}
+	game.Run()
+}
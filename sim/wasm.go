@@ -0,0 +1,172 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"syscall/js"
+)
+
+// The WebAssembly build puts the engine and its agents in the browser, so a
+// page can play by the same rules code as the simulations. Build it with
+//
+//	GOOS=js GOARCH=wasm go build -o ../web/beeholder.wasm
+//	cp "$(go env GOROOT)/lib/wasm/wasm_exec.js" ../web/   # misc/wasm before Go 1.24
+//
+// and load it with web/beeholder-engine.js. The bridge is a global object,
+// beeholder, whose functions take and return plain objects shaped like the
+// HTTP API's JSON (see api.go and local.go):
+//
+//	newGame(request)             CreateGameRequest; returns the public view
+//	view(game, seat)             the seat's view; seat -1 for the public view
+//	legalMoves(game, seat)       the seat's open Decision
+//	applyMove(game, seat, move)  makes a Move; returns the seat's view
+//	agentDecide(request)         AIRequest; returns an AIResponse
+//	endGame(game)                abandons a game
+//
+// Failures return {error: message} instead. Server agents play their seats
+// before each call returns, as they do over HTTP.
+
+// RunWASMBridge publishes the bridge and keeps the program alive to serve it
+func RunWASMBridge() {
+	games := NewAPIServer()
+	bridge := map[string]any{
+		"newGame": bridgeFunc(1, func(args []js.Value) (any, error) {
+			req := CreateGameRequest{Players: 4}
+			if err := decodeValue(args[0], &req); err != nil {
+				return nil, err
+			}
+			session, err := games.newSession(req)
+			if err != nil {
+				return nil, err
+			}
+			session.Start()
+			return session.View(-1), nil
+		}),
+		"view": bridgeFunc(2, func(args []js.Value) (any, error) {
+			session, err := bridgeSession(games, args[0])
+			if err != nil {
+				return nil, err
+			}
+			if args[1].Type() != js.TypeNumber {
+				return nil, errors.New("seat must be a number")
+			}
+			seat := args[1].Int()
+			if seat < -1 || seat >= len(session.Remote) {
+				return nil, fmt.Errorf("no seat %d", seat)
+			}
+			return session.View(seat), nil
+		}),
+		"legalMoves": bridgeFunc(2, func(args []js.Value) (any, error) {
+			session, seat, err := bridgeSeat(games, args[0], args[1])
+			if err != nil {
+				return nil, err
+			}
+			decision := session.Decision(seat)
+			if decision == nil {
+				decision = &Decision{Moves: []Move{}}
+			}
+			return decision, nil
+		}),
+		"applyMove": bridgeFunc(3, func(args []js.Value) (any, error) {
+			session, seat, err := bridgeSeat(games, args[0], args[1])
+			if err != nil {
+				return nil, err
+			}
+			var move Move
+			if err := decodeValue(args[2], &move); err != nil {
+				return nil, err
+			}
+			if err := session.Submit(seat, move); err != nil {
+				return nil, err
+			}
+			return session.View(seat), nil
+		}),
+		"agentDecide": bridgeFunc(1, func(args []js.Value) (any, error) {
+			var req AIRequest
+			if err := decodeValue(args[0], &req); err != nil {
+				return nil, err
+			}
+			return req.Decide()
+		}),
+		"endGame": bridgeFunc(1, func(args []js.Value) (any, error) {
+			session, err := bridgeSession(games, args[0])
+			if err != nil {
+				return nil, err
+			}
+			games.remove(session)
+			return map[string]any{}, nil
+		}),
+	}
+	js.Global().Set("beeholder", js.ValueOf(bridge))
+	if ready := js.Global().Get("beeholderReady"); ready.Type() == js.TypeFunction {
+		ready.Invoke()
+	}
+	select {}
+}
+
+// bridgeFunc wraps a bridge function, checking its argument count and
+// turning its result or error into a plain object
+func bridgeFunc(numArgs int, f func(args []js.Value) (any, error)) js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) any {
+		if len(args) < numArgs {
+			return encodeValue(map[string]string{"error": fmt.Sprintf("expected %d arguments, got %d", numArgs, len(args))})
+		}
+		result, err := f(args)
+		if err != nil {
+			return encodeValue(map[string]string{"error": err.Error()})
+		}
+		return encodeValue(result)
+	})
+}
+
+// bridgeSession looks up a game by ID
+func bridgeSession(games *APIServer, id js.Value) (*Session, error) {
+	session := games.find(id.String())
+	if session == nil {
+		return nil, fmt.Errorf("no game %q", id.String())
+	}
+	return session, nil
+}
+
+// bridgeSeat looks up a game and one of its remote seats
+func bridgeSeat(games *APIServer, id js.Value, seatValue js.Value) (*Session, int, error) {
+	session, err := bridgeSession(games, id)
+	if err != nil {
+		return nil, 0, err
+	}
+	if seatValue.Type() != js.TypeNumber {
+		return nil, 0, errors.New("seat must be a number")
+	}
+	seat := seatValue.Int()
+	if seat < 0 || seat >= len(session.Remote) {
+		return nil, 0, fmt.Errorf("no seat %d", seat)
+	}
+	if !session.Remote[seat] {
+		return nil, 0, fmt.Errorf("seat %d is played by the engine", seat)
+	}
+	return session, seat, nil
+}
+
+// decodeValue reads a JS object into v by way of JSON
+func decodeValue(value js.Value, v any) error {
+	if value.IsUndefined() || value.IsNull() {
+		return nil
+	}
+	text := js.Global().Get("JSON").Call("stringify", value).String()
+	if err := json.Unmarshal([]byte(text), v); err != nil {
+		return fmt.Errorf("invalid argument: %v", err)
+	}
+	return nil
+}
+
+// encodeValue converts v to a JS object by way of JSON
+func encodeValue(v any) js.Value {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(map[string]string{"error": err.Error()})
+	}
+	return js.Global().Get("JSON").Call("parse", string(data))
+}
@@ -0,0 +1,8 @@
+//go:build !(js && wasm)
+
+package main
+
+// RunWASMBridge is only available in the WebAssembly build (see wasm.go)
+func RunWASMBridge() {
+	panic("the engine bridge needs GOOS=js GOARCH=wasm")
+}
@@ -0,0 +1,40 @@
+// Loads the Go engine compiled to WebAssembly (see sim/wasm.go) and wraps
+// its bridge in methods that throw on failure. Needs wasm_exec.js from the
+// Go distribution to be loaded first.
+//
+//   const engine = await loadBeeEngine('beeholder.wasm');
+//   let view = engine.newGame({ players: 3, agents: ['human', 'hard', 'greedy'] });
+//   const decision = engine.legalMoves(view.game, 0);
+//   view = engine.applyMove(view.game, 0, decision.moves[0]);
+//
+// Moves, decisions and views have the same JSON shapes as the HTTP API.
+async function loadBeeEngine(url = 'beeholder.wasm') {
+  const go = new Go();
+  const ready = new Promise(resolve => { globalThis.beeholderReady = resolve; });
+  let instance;
+  if (WebAssembly.instantiateStreaming) {
+    ({ instance } = await WebAssembly.instantiateStreaming(fetch(url), go.importObject));
+  } else {
+    const bytes = await (await fetch(url)).arrayBuffer();
+    ({ instance } = await WebAssembly.instantiate(bytes, go.importObject));
+  }
+  go.run(instance);
+  await ready;
+
+  const bridge = globalThis.beeholder;
+  const call = (name, ...args) => {
+    const result = bridge[name](...args);
+    if (result && result.error) throw new Error(result.error);
+    return result;
+  };
+  return {
+    newGame: request => call('newGame', request || {}),
+    view: (game, seat = -1) => call('view', game, seat),
+    legalMoves: (game, seat) => call('legalMoves', game, seat),
+    applyMove: (game, seat, move) => call('applyMove', game, seat, move),
+    agentDecide: request => call('agentDecide', request),
+    endGame: game => call('endGame', game),
+  };
+}
+
+if (typeof module !== 'undefined') module.exports = { loadBeeEngine };